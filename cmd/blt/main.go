@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/aocamilo/broken-links-tester/pkg/export"
+	"github.com/urfave/cli/v2"
+)
+
+// Exit codes of the blt command
+const (
+	exitFailed = 1 // The crawl found more problems than allowed
+	exitError  = 2 // The crawl could not run
+)
+
+func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if exitErr, ok := err.(cli.ExitCoder); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		os.Exit(exitError)
+	}
+}
+
+// newApp defines the command-line flags and runs the crawl
+func newApp() *cli.App {
+	return &cli.App{
+		Name:      "blt",
+		Usage:     "check a website for broken links",
+		ArgsUsage: "URL",
+		Flags: []cli.Flag{
+			&cli.IntFlag{Name: "depth", Aliases: []string{"d"}, Value: 2, Usage: "how many links deep to crawl"},
+			&cli.StringFlag{Name: "scope", Value: crawler.ScopeAll, Usage: "pages whose links are followed: all, host, domain or prefix"},
+			&cli.StringFlag{Name: "scope-prefix", Usage: "URL prefix used by the prefix scope (default: URL)"},
+			&cli.IntFlag{Name: "concurrency", Aliases: []string{"c"}, Value: crawler.DefaultBrowserOptions().MaxConcurrent, Usage: "pages loaded in parallel"},
+			&cli.DurationFlag{Name: "timeout", Value: crawler.DefaultBrowserOptions().Timeout, Usage: "per-page load timeout, 0 for none"},
+			&cli.DurationFlag{Name: "max-duration", Usage: "bound for the whole crawl; results are partial when it passes"},
+			&cli.IntFlag{Name: "retries", Value: crawler.DefaultRetryPolicy().MaxAttempts - 1, Usage: "how often a request failing with a network error or a retryable status is retried"},
+			&cli.DurationFlag{Name: "retry-delay", Value: crawler.DefaultRetryPolicy().BaseDelay, Usage: "delay before the first retry, doubled for each further one"},
+			&cli.StringSliceFlag{Name: "normalize", Usage: "URL normalization rules: strip_fragment, sort_query, drop_tracking, lowercase_host, remove_default_port, remove_trailing_slash, honor_canonical or none (default: all but honor_canonical)"},
+			&cli.BoolFlag{Name: "soft-errors", Usage: "report pages answering 2xx with a not-found page as broken"},
+			&cli.StringFlag{Name: "fetcher", Value: crawler.BackendHTTP, Usage: "crawler backend: http, or playwright to render pages in an installed browser"},
+			&cli.StringSliceFlag{Name: "resources", Usage: "sub-resource types to check: image, script, stylesheet, iframe, media or all"},
+			&cli.StringSliceFlag{Name: "include", Usage: "only enqueue URLs matching one of these patterns"},
+			&cli.StringSliceFlag{Name: "exclude", Usage: "never enqueue URLs matching any of these patterns"},
+			&cli.BoolFlag{Name: "ignore-robots", Usage: "ignore robots.txt rules and Crawl-delay"},
+			&cli.StringFlag{Name: "format", Aliases: []string{"f"}, Value: formatText, Usage: "output format: " + formatNames()},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "write the report to this file instead of stdout"},
+			&cli.StringFlag{Name: "source-root", Usage: "repository directory the site is served from, so SARIF results point at files"},
+			&cli.StringSliceFlag{Name: "fail-on", Value: cli.NewStringSlice(failBroken), Usage: "problems that fail the run: broken, redirect, skipped or an error category like http_4xx"},
+			&cli.IntFlag{Name: "max-broken", Usage: "how many problems are tolerated before the run fails"},
+			&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "log crawler progress to stderr"},
+		},
+		Action: run,
+	}
+}
+
+func run(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("exactly one URL is required", exitError)
+	}
+	baseURL := c.Args().First()
+
+	if !c.Bool("verbose") {
+		log.SetOutput(io.Discard)
+	}
+
+	opts, err := crawlOptions(c)
+	if err != nil {
+		return cli.Exit(err, exitError)
+	}
+	rules, err := parseFailRules(c.StringSlice("fail-on"))
+	if err != nil {
+		return cli.Exit(err, exitError)
+	}
+	var exporter export.Exporter
+	if format := c.String("format"); format != formatText {
+		if exporter, err = export.Get(format); err != nil {
+			return cli.Exit(fmt.Sprintf("unknown format %q, use one of %s", format, formatNames()), exitError)
+		}
+	}
+
+	out := io.Writer(os.Stdout)
+	if path := c.String("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("failed to create output file: %v", err), exitError)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	f, err := crawler.NewFetcher(c.String("fetcher"))
+	if err != nil {
+		return cli.Exit(err, exitError)
+	}
+	cr := crawler.NewCrawlerWithFetcher(f)
+	defer cr.Close()
+
+	// Ctrl-C stops the crawl and reports what was checked so far
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	result := cr.CheckLinksWithOptions(ctx, baseURL, c.Int("depth"), opts)
+	if exporter != nil {
+		err = exporter.Export(out, export.Report{
+			URL:         baseURL,
+			Links:       result.Links,
+			Truncated:   result.Truncated,
+			GeneratedAt: time.Now(),
+			SourceRoot:  c.String("source-root"),
+		})
+	} else {
+		err = writeText(out, result)
+	}
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("failed to write report: %v", err), exitError)
+	}
+
+	if problems := countProblems(result.Links, rules); problems > c.Int("max-broken") {
+		return cli.Exit(fmt.Sprintf("%d problems found, at most %d allowed", problems, c.Int("max-broken")), exitFailed)
+	}
+	return nil
+}
+
+// crawlOptions builds the crawl options from the command-line flags
+func crawlOptions(c *cli.Context) (crawler.CrawlOptions, error) {
+	opts := crawler.DefaultCrawlOptions()
+
+	if c.Int("depth") < 0 {
+		return opts, fmt.Errorf("depth must not be negative")
+	}
+	if c.Int("concurrency") < 1 {
+		return opts, fmt.Errorf("concurrency must be at least 1")
+	}
+	opts.Browser.MaxConcurrent = c.Int("concurrency")
+	opts.Politeness.MaxPerHost = min(opts.Politeness.MaxPerHost, opts.Browser.MaxConcurrent)
+	// A zero timeout waits for pages as long as the crawl runs
+	if c.Duration("timeout") < 0 {
+		return opts, fmt.Errorf("timeout must not be negative")
+	}
+	opts.Browser.Timeout = c.Duration("timeout")
+	if c.Duration("max-duration") < 0 {
+		return opts, fmt.Errorf("max-duration must not be negative")
+	}
+	opts.MaxDuration = c.Duration("max-duration")
+
+	if c.Int("retries") < 0 {
+		return opts, fmt.Errorf("retries must not be negative")
+	}
+	// MaxAttempts counts the first attempt as well
+	opts.Retry.MaxAttempts = c.Int("retries") + 1
+	if c.Duration("retry-delay") < 0 {
+		return opts, fmt.Errorf("retry-delay must not be negative")
+	}
+	opts.Retry.BaseDelay = c.Duration("retry-delay")
+	opts.SoftErrors.Enabled = c.Bool("soft-errors")
+
+	scope := c.String("scope")
+	if !slices.Contains([]string{crawler.ScopeAll, crawler.ScopeHost, crawler.ScopeDomain, crawler.ScopePrefix}, scope) {
+		return opts, fmt.Errorf("unknown scope %q", scope)
+	}
+	if c.String("scope-prefix") != "" && scope != crawler.ScopePrefix {
+		return opts, fmt.Errorf("scope-prefix requires --scope %s", crawler.ScopePrefix)
+	}
+	opts.Scope = crawler.Scope{Mode: scope, Prefix: c.String("scope-prefix")}
+	opts.IgnoreRobots = c.Bool("ignore-robots")
+
+	var err error
+	if opts.Resources, err = crawler.ResourceTypes(c.StringSlice("resources")); err != nil {
+		return opts, err
+	}
+	if opts.Normalize, err = crawler.NormalizeRules(c.StringSlice("normalize")); err != nil {
+		return opts, err
+	}
+	include, exclude := c.StringSlice("include"), c.StringSlice("exclude")
+	if len(include) > 0 || len(exclude) > 0 {
+		if opts.Filter, err = crawler.NewURLFilter(include, exclude); err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
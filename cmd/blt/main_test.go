@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/urfave/cli/v2"
+)
+
+// parseFlags runs the blt flags on args and returns the crawl options and fetcher they select
+func parseFlags(t *testing.T, args ...string) (crawler.CrawlOptions, string, error) {
+	t.Helper()
+	var opts crawler.CrawlOptions
+	var fetcher string
+	var optsErr error
+	app := newApp()
+	app.Action = func(c *cli.Context) error {
+		opts, optsErr = crawlOptions(c)
+		fetcher = c.String("fetcher")
+		return nil
+	}
+	if err := app.Run(append([]string{"blt"}, append(args, "https://example.com")...)); err != nil {
+		t.Fatal(err)
+	}
+	return opts, fetcher, optsErr
+}
+
+func TestDefaultFlags(t *testing.T) {
+	opts, fetcher, err := parseFlags(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetcher != crawler.BackendHTTP {
+		t.Errorf("fetcher = %q, want %q", fetcher, crawler.BackendHTTP)
+	}
+	if opts.Browser.Timeout != crawler.DefaultBrowserOptions().Timeout {
+		t.Errorf("timeout = %s, want the default", opts.Browser.Timeout)
+	}
+}
+
+func TestCrawlOptionFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{"timeout", []string{"--timeout", "5s"}, false},
+		{"zero timeout", []string{"--timeout", "0s"}, false},
+		{"negative timeout", []string{"--timeout", "-1s"}, true},
+		{"negative max duration", []string{"--max-duration", "-1m"}, true},
+		{"negative retries", []string{"--retries", "-1"}, true},
+		{"negative retry delay", []string{"--retry-delay", "-1s"}, true},
+		{"unknown normalization rule", []string{"--normalize", "lowercase_path"}, true},
+		{"scope prefix without prefix scope", []string{"--scope-prefix", "https://example.com/docs"}, true},
+		{"scope prefix", []string{"--scope", "prefix", "--scope-prefix", "https://example.com/docs"}, false},
+		{"negative depth", []string{"--depth", "-1"}, true},
+		{"zero concurrency", []string{"--concurrency", "0"}, true},
+		{"unknown scope", []string{"--scope", "site"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := parseFlags(t, tt.args...); (err != nil) != tt.wantErr {
+				t.Errorf("crawlOptions() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+	if opts, _, _ := parseFlags(t, "--timeout", "5s"); opts.Browser.Timeout != 5*time.Second {
+		t.Errorf("timeout = %s, want 5s", opts.Browser.Timeout)
+	}
+}
+
+func TestCheckFlags(t *testing.T) {
+	opts, _, err := parseFlags(t, "--retries", "0", "--retry-delay", "1s", "--normalize", "strip_fragment", "--soft-errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Retry.MaxAttempts != 1 || opts.Retry.BaseDelay != time.Second {
+		t.Errorf("retry = %+v, want 1 attempt and a 1s delay", opts.Retry)
+	}
+	if opts.Normalize != (crawler.NormalizeOptions{StripFragment: true}) {
+		t.Errorf("normalize = %+v, want only strip_fragment", opts.Normalize)
+	}
+	if !opts.SoftErrors.Enabled {
+		t.Error("soft errors disabled with --soft-errors")
+	}
+
+	if opts, _, _ = parseFlags(t); opts.SoftErrors.Enabled || opts.Normalize != crawler.DefaultNormalizeOptions() ||
+		opts.Retry.MaxAttempts != crawler.DefaultRetryPolicy().MaxAttempts {
+		t.Errorf("defaults changed: soft errors %v, normalize %+v, retry %+v", opts.SoftErrors.Enabled, opts.Normalize, opts.Retry)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/aocamilo/broken-links-tester/pkg/export"
+)
+
+// formatText lists the broken links for people reading the CI log;
+// every other format comes from the export package
+const formatText = "text"
+
+func formatNames() string {
+	return strings.Join(append([]string{formatText}, export.Formats()...), ", ")
+}
+
+// Problems --fail-on can select besides error categories
+const (
+	failBroken   = "broken"   // Any link that is not working
+	failRedirect = "redirect" // Any link that was redirected
+	failSkipped  = "skipped"  // Any link that was not checked, e.g. because of robots.txt
+)
+
+// failRule decides whether a link counts as a problem
+type failRule func(models.LinkStatus) bool
+
+func parseFailRules(names []string) ([]failRule, error) {
+	rules := make([]failRule, 0, len(names))
+	for _, name := range names {
+		switch {
+		case name == failBroken:
+			rules = append(rules, func(l models.LinkStatus) bool { return !l.IsWorking && !l.Skipped })
+		case name == failRedirect:
+			rules = append(rules, func(l models.LinkStatus) bool { return len(l.Redirects) > 0 })
+		case name == failSkipped:
+			rules = append(rules, func(l models.LinkStatus) bool { return l.Skipped })
+		case slices.Contains(models.ErrorCategories, models.ErrorCategory(name)):
+			category := models.ErrorCategory(name)
+			rules = append(rules, func(l models.LinkStatus) bool { return l.ErrorCategory == category })
+		default:
+			return nil, fmt.Errorf("unknown --fail-on value %q", name)
+		}
+	}
+	return rules, nil
+}
+
+// countProblems returns how many links match any of the rules
+func countProblems(links []models.LinkStatus, rules []failRule) int {
+	n := 0
+	for _, l := range links {
+		if slices.ContainsFunc(rules, func(rule failRule) bool { return rule(l) }) {
+			n++
+		}
+	}
+	return n
+}
+
+// writeText lists the links that are not working followed by a summary
+func writeText(w io.Writer, result crawler.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	broken, skipped := 0, 0
+	for _, l := range result.Links {
+		switch {
+		case l.Skipped:
+			skipped++
+		case !l.IsWorking:
+			broken++
+			reason := l.Error
+			if reason == "" && l.SoftError != nil {
+				reason = "soft 404: " + l.SoftError.Rule
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", statusText(l), l.URL, l.ParentURL, reason)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d links checked, %d broken, %d skipped\n", len(result.Links), broken, skipped)
+	if err == nil && result.Truncated {
+		_, err = fmt.Fprintln(w, "The crawl was stopped early; results are partial")
+	}
+	return err
+}
+
+func statusText(l models.LinkStatus) string {
+	if l.StatusCode == 0 {
+		return string(l.ErrorCategory)
+	}
+	return fmt.Sprint(l.StatusCode)
+}
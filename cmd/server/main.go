@@ -8,7 +8,12 @@ import (
 )
 
 func main() {
-	server, err := api.NewServer()
+	cfg, err := api.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	server, err := api.NewServer(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
@@ -21,6 +26,10 @@ func main() {
 
 	// Pass the port without a colon - the server.Run method will add it
 	if err := server.Run(port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		log.Printf("Server shutdown error: %v", err)
+	}
+
+	if err := server.Close(); err != nil {
+		log.Fatalf("Failed to release resources: %v", err)
 	}
 } 
\ No newline at end of file
@@ -22,7 +22,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml",
+                    "application/sarif+json",
+                    "text/csv",
+                    "text/markdown",
+                    "text/html"
                 ],
                 "tags": [
                     "links"
@@ -37,6 +42,44 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.CheckRequest"
                         }
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "dns",
+                                "tls",
+                                "connection_refused",
+                                "connection_reset",
+                                "timeout",
+                                "redirect_loop",
+                                "http_4xx",
+                                "http_5xx",
+                                "broken_fragment",
+                                "soft_404",
+                                "network",
+                                "unknown"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Only return links in these error categories",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "junit",
+                            "sarif",
+                            "csv",
+                            "markdown",
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Report format; the Accept header is used when omitted",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -47,6 +90,16 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/models.LinkStatus"
                             }
+                        },
+                        "headers": {
+                            "X-Crawl-Truncated": {
+                                "type": "string",
+                                "description": "true when max_duration passed and the results are partial"
+                            },
+                            "X-Run-ID": {
+                                "type": "string",
+                                "description": "ID of the run kept in the run store"
+                            }
                         }
                     },
                     "400": {
@@ -69,51 +122,2103 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.CheckRequest": {
-            "type": "object",
-            "required": [
-                "url"
-            ],
-            "properties": {
-                "depth": {
-                    "type": "integer",
-                    "maximum": 4,
-                    "minimum": 0
-                },
-                "url": {
-                    "type": "string"
+        },
+        "/jobs": {
+            "post": {
+                "description": "Queues a crawl and returns the job immediately; poll the job for progress and results",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Start an asynchronous link check",
+                "parameters": [
+                    {
+                        "description": "URL and depth parameters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CheckRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
                 }
             }
         },
-        "models.LinkStatus": {
-            "type": "object",
-            "properties": {
-                "depth": {
-                    "type": "integer"
-                },
-                "error": {
-                    "type": "string"
-                },
-                "is_working": {
-                    "type": "boolean"
-                },
-                "last_checked": {
-                    "type": "string"
-                },
-                "parent_url": {
-                    "type": "string"
-                },
-                "response_time": {
-                    "type": "string"
-                },
-                "status_code": {
-                    "type": "integer"
-                },
-                "url": {
-                    "type": "string"
+        "/jobs/{id}": {
+            "get": {
+                "description": "Returns the state, progress counters, broken link counts per error category and the results of a job",
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/sarif+json",
+                    "text/csv",
+                    "text/markdown",
+                    "text/html"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Get a crawl job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "dns",
+                                "tls",
+                                "connection_refused",
+                                "connection_reset",
+                                "timeout",
+                                "redirect_loop",
+                                "http_4xx",
+                                "http_5xx",
+                                "broken_fragment",
+                                "soft_404",
+                                "network",
+                                "unknown"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Only return results in these error categories",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "junit",
+                            "sarif",
+                            "csv",
+                            "markdown",
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Export the results in this format instead of returning the job; the Accept header is used when omitted",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels a queued or running job",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Cancel a crawl job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/events": {
+            "get": {
+                "description": "Streams Server-Sent Events for a job: a \"result\" event per checked link (replaying those already recorded), a \"progress\" event every second and a final \"done\" event with the job state",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Stream crawl job updates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "result events",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/graph": {
+            "get": {
+                "description": "Returns every checked URL of a finished job as a node and every page linking to it as an edge, with the number of occurrences. Pass target to only get the referrers of one URL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Get the link graph of a crawl job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return edges pointing at this URL",
+                        "name": "target",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkGraph"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifiers": {
+            "get": {
+                "description": "Returns every notifier, oldest first; secrets are never returned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifiers"
+                ],
+                "summary": "List notifiers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Notifier"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Posts every finished run the rule fires on to a webhook: always, when the run found broken links, or when links broke since the previous run of the same URL. Payloads are generic JSON, Slack messages or Teams adaptive cards, signed with HMAC-SHA256 when a secret is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifiers"
+                ],
+                "summary": "Create a notifier",
+                "parameters": [
+                    {
+                        "description": "Webhook URL, format and rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NotifierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notifier"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifiers/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifiers"
+                ],
+                "summary": "Get a notifier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notifier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notifier"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the settings of a notifier; an empty secret keeps the current one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifiers"
+                ],
+                "summary": "Replace a notifier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notifier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook URL, format and rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NotifierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notifier"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a notifier; deliveries already started are still sent",
+                "tags": [
+                    "notifiers"
+                ],
+                "summary": "Delete a notifier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notifier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifiers/{id}/deliveries": {
+            "get": {
+                "description": "Returns the logged deliveries of a notifier with every attempt, most recent first. Only the last 500 deliveries of all notifiers are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifiers"
+                ],
+                "summary": "List the deliveries of a notifier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notifier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Delivery"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/runs": {
+            "get": {
+                "description": "Returns the kept runs without their results, most recent first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "runs"
+                ],
+                "summary": "List past runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only runs of this start URL",
+                        "name": "url",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "At most this many runs",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Run"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes the runs that finished longer than older_than ago, and applies the server's retention policy",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "runs"
+                ],
+                "summary": "Delete old runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Age of the runs to delete, e.g. 168h",
+                        "name": "older_than",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RunDeletion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/runs/{id}": {
+            "get": {
+                "description": "Returns a kept run with its config, summary and results",
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/sarif+json",
+                    "text/csv",
+                    "text/markdown",
+                    "text/html"
+                ],
+                "tags": [
+                    "runs"
+                ],
+                "summary": "Get a past run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "dns",
+                                "tls",
+                                "connection_refused",
+                                "connection_reset",
+                                "timeout",
+                                "redirect_loop",
+                                "http_4xx",
+                                "http_5xx",
+                                "broken_fragment",
+                                "soft_404",
+                                "network",
+                                "unknown"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Only return results in these error categories",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "junit",
+                            "sarif",
+                            "csv",
+                            "markdown",
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Export the results in this format instead of returning the run; the Accept header is used when omitted",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Run"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "runs"
+                ],
+                "summary": "Delete a past run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/runs/{id}/diff/{head}": {
+            "get": {
+                "description": "Classifies every link of the head run against the older base run as newly_broken, fixed, still_broken, discovered, disappeared, unchanged or skipped, and flags changed status codes and response time regressions. Unchanged links without a flag are only counted.",
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/sarif+json",
+                    "text/csv",
+                    "text/markdown",
+                    "text/html"
+                ],
+                "tags": [
+                    "runs"
+                ],
+                "summary": "Compare two runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Base run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Head run ID",
+                        "name": "head",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Flag links whose response time grew by at least this factor (default 2)",
+                        "name": "slowdown_factor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "and by at least this much, e.g. 500ms (default)",
+                        "name": "min_slowdown",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "junit",
+                            "sarif",
+                            "csv",
+                            "markdown",
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Export the head run with the diff in this format; the Accept header is used when omitted",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RunDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/runs/{id}/graph": {
+            "get": {
+                "description": "Returns every checked URL of a kept run as a node and every page linking to it as an edge. Pass target to only get the referrers of one URL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "runs"
+                ],
+                "summary": "Get the link graph of a past run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return edges pointing at this URL",
+                        "name": "target",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkGraph"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules": {
+            "get": {
+                "description": "Returns every schedule with its next run and the state of its last job, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "List recurring crawls",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Schedule"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Starts a crawl job whenever the cron expression or interval is due. A due run is skipped while the previous job of the schedule is still queued or running.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Create a recurring crawl",
+                "parameters": [
+                    {
+                        "description": "Crawl request and timing",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Get a recurring crawl",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Schedule"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the crawl request and timing of a schedule and plans its next run; the run history is kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Replace a recurring crawl",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Crawl request and timing",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a schedule; a job it already started keeps running",
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Delete a recurring crawl",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.Attempt": {
+            "type": "object",
+            "properties": {
+                "delay": {
+                    "description": "Delay is how long the crawler waited before the next attempt",
+                    "type": "string"
+                },
+                "duration": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_category": {
+                    "$ref": "#/definitions/models.ErrorCategory"
+                },
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CheckRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "concurrency": {
+                    "description": "Concurrency is how many pages are loaded in parallel",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "depth": {
+                    "type": "integer",
+                    "maximum": 4,
+                    "minimum": 0
+                },
+                "exclude": {
+                    "description": "Exclude drops URLs matching any of these globs (\"re:\" for regexes)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ignore_robots": {
+                    "description": "IgnoreRobots disables robots.txt rules and Crawl-delay, for sites we own",
+                    "type": "boolean"
+                },
+                "include": {
+                    "description": "Include keeps only URLs matching one of these globs (\"re:\" for regexes)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "load_leaves": {
+                    "description": "LoadLeaves loads links that won't be followed as full pages instead of\nchecking them with HEAD, so soft 404s are detected on them too",
+                    "type": "boolean"
+                },
+                "max_duration": {
+                    "description": "MaxDuration bounds the whole crawl as a Go duration such as \"5m\";\nwhen it passes the partial results are returned marked as truncated",
+                    "type": "string",
+                    "example": "5m"
+                },
+                "max_per_host": {
+                    "description": "MaxPerHost caps concurrent requests to a single host",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "max_redirects": {
+                    "description": "MaxRedirects flags redirect chains with more hops than this as long_chain",
+                    "type": "integer",
+                    "maximum": 20,
+                    "minimum": 1
+                },
+                "normalize": {
+                    "description": "Normalize lists the URL normalization rules; empty uses the defaults",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "requests_per_second": {
+                    "description": "RequestsPerSecond caps the request rate to a single host",
+                    "type": "number"
+                },
+                "resources": {
+                    "description": "Resources selects the sub-resource types checked besides anchors",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "retries": {
+                    "description": "Retries is how many times a failed page load is retried",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "retry_delay": {
+                    "description": "RetryDelay is the pause before the first retry, e.g. \"2s\"; it doubles for every further retry",
+                    "type": "string",
+                    "example": "2s"
+                },
+                "retry_statuses": {
+                    "description": "RetryStatuses are the response status codes that are retried",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        429,
+                        502,
+                        503,
+                        504
+                    ]
+                },
+                "scope": {
+                    "description": "Scope limits which pages have their links followed; links leaving\nthe scope are still checked but not expanded",
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "host",
+                        "domain",
+                        "prefix"
+                    ]
+                },
+                "scope_prefix": {
+                    "description": "ScopePrefix is the URL prefix for the \"prefix\" scope, which it requires;\ndefaults to the URL",
+                    "type": "string"
+                },
+                "soft_errors": {
+                    "description": "SoftErrors enables the detection of 2xx pages that look like not-found pages",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SoftErrorRequest"
+                        }
+                    ]
+                },
+                "timeout": {
+                    "description": "Timeout is the per-page load timeout, e.g. \"30s\"",
+                    "type": "string",
+                    "example": "30s"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "wait_until": {
+                    "description": "WaitUntil is the page load state the browser waits for",
+                    "type": "string",
+                    "enum": [
+                        "load",
+                        "domcontentloaded",
+                        "networkidle"
+                    ]
+                }
+            }
+        },
+        "models.CrawlProgress": {
+            "type": "object",
+            "properties": {
+                "broken": {
+                    "type": "integer"
+                },
+                "in_flight": {
+                    "type": "integer"
+                },
+                "queued": {
+                    "type": "integer"
+                },
+                "visited": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attempt"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "notifier_id": {
+                    "type": "string"
+                },
+                "rule": {
+                    "$ref": "#/definitions/models.NotifyRule"
+                },
+                "run_id": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "delivered",
+                        "failed"
+                    ]
+                },
+                "status_code": {
+                    "description": "StatusCode is the response status of the last attempt",
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ErrorCategory": {
+            "type": "string",
+            "enum": [
+                "dns",
+                "tls",
+                "connection_refused",
+                "connection_reset",
+                "timeout",
+                "redirect_loop",
+                "http_4xx",
+                "http_5xx",
+                "broken_fragment",
+                "soft_404",
+                "network",
+                "unknown"
+            ],
+            "x-enum-comments": {
+                "ErrorBrokenFragment": "The page exists but the #fragment does not",
+                "ErrorConnectionRefused": "Nothing listens on the port",
+                "ErrorConnectionReset": "The server dropped the connection",
+                "ErrorDNS": "The host name does not resolve",
+                "ErrorHTTPClient": "The server answered with a 4xx status",
+                "ErrorHTTPServer": "The server answered with a 5xx status",
+                "ErrorNetwork": "Any other network failure",
+                "ErrorRedirectLoop": "The redirect chain loops or never ends",
+                "ErrorSoft404": "A 2xx page that looks like a not-found page",
+                "ErrorTLS": "Certificate or handshake failure",
+                "ErrorTimeout": "No response in time"
+            },
+            "x-enum-varnames": [
+                "ErrorDNS",
+                "ErrorTLS",
+                "ErrorConnectionRefused",
+                "ErrorConnectionReset",
+                "ErrorTimeout",
+                "ErrorRedirectLoop",
+                "ErrorHTTPClient",
+                "ErrorHTTPServer",
+                "ErrorBrokenFragment",
+                "ErrorSoft404",
+                "ErrorNetwork",
+                "ErrorUnknown"
+            ]
+        },
+        "models.Job": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "description": "Config is the request the job was submitted with",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CheckRequest"
+                        }
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_counts": {
+                    "description": "ErrorCounts counts the broken links recorded so far per error category",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "progress": {
+                    "$ref": "#/definitions/models.CrawlProgress"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkStatus"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "$ref": "#/definitions/models.JobState"
+                },
+                "truncated": {
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.JobState": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "done",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "JobQueued",
+                "JobRunning",
+                "JobDone",
+                "JobFailed",
+                "JobCancelled"
+            ]
+        },
+        "models.LinkChange": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "$ref": "#/definitions/models.LinkStatus"
+                },
+                "before": {
+                    "description": "Before and After are the link in the older and newer run, when it was found",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.LinkStatus"
+                        }
+                    ]
+                },
+                "change": {
+                    "$ref": "#/definitions/models.LinkChangeKind"
+                },
+                "resource_type": {
+                    "type": "string"
+                },
+                "slower": {
+                    "description": "Slower is set when the response time regressed beyond the diff thresholds",
+                    "type": "boolean"
+                },
+                "status_changed": {
+                    "description": "StatusChanged is set when both runs got a response but with different status codes",
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LinkChangeKind": {
+            "type": "string",
+            "enum": [
+                "newly_broken",
+                "fixed",
+                "still_broken",
+                "discovered",
+                "disappeared",
+                "unchanged",
+                "skipped"
+            ],
+            "x-enum-comments": {
+                "ChangeDisappeared": "Only found by the older run",
+                "ChangeDiscovered": "Working now, not found or skipped before",
+                "ChangeFixed": "Working now, broken before",
+                "ChangeNewlyBroken": "Broken now, working or unknown before",
+                "ChangeSkipped": "Not requested by the newer run, e.g. disallowed by robots.txt",
+                "ChangeStillBroken": "Broken in both runs",
+                "ChangeUnchanged": "Working in both runs"
+            },
+            "x-enum-varnames": [
+                "ChangeNewlyBroken",
+                "ChangeFixed",
+                "ChangeStillBroken",
+                "ChangeDiscovered",
+                "ChangeDisappeared",
+                "ChangeUnchanged",
+                "ChangeSkipped"
+            ]
+        },
+        "models.LinkEdge": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "resource_type": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LinkGraph": {
+            "type": "object",
+            "properties": {
+                "edges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkEdge"
+                    }
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkNode"
+                    }
+                }
+            }
+        },
+        "models.LinkNode": {
+            "type": "object",
+            "properties": {
+                "is_working": {
+                    "type": "boolean"
+                },
+                "resource_type": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "boolean"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LinkSource": {
+            "type": "object",
+            "properties": {
+                "aria_label": {
+                    "type": "string"
+                },
+                "column": {
+                    "type": "integer"
+                },
+                "line": {
+                    "description": "Line and Column locate the tag in the HTML source; only the static fetcher sets them",
+                    "type": "integer"
+                },
+                "parent_url": {
+                    "type": "string"
+                },
+                "selector": {
+                    "description": "Selector is a CSS selector path to the element",
+                    "type": "string",
+                    "example": "main#content \u003e p:nth-of-type(2) \u003e a:nth-of-type(1)"
+                },
+                "text": {
+                    "description": "Text is the anchor text, or the alt text of an image",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LinkStatus": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Attempts is the history of every try when the URL was retried",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attempt"
+                    }
+                },
+                "broken_fragment": {
+                    "description": "BrokenFragment is set for links whose #fragment matches no id or\nanchor name on the target page; URL then includes the fragment",
+                    "type": "boolean"
+                },
+                "canonical_url": {
+                    "description": "CanonicalURL is the \u003clink rel=canonical\u003e target declared by the page",
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_category": {
+                    "description": "ErrorCategory classifies broken links; Error keeps the raw message",
+                    "enum": [
+                        "dns",
+                        "tls",
+                        "connection_refused",
+                        "connection_reset",
+                        "timeout",
+                        "redirect_loop",
+                        "http_4xx",
+                        "http_5xx",
+                        "broken_fragment",
+                        "soft_404",
+                        "network",
+                        "unknown"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ErrorCategory"
+                        }
+                    ]
+                },
+                "final_url": {
+                    "description": "FinalURL is where the redirect chain ended, if URL redirected",
+                    "type": "string"
+                },
+                "is_working": {
+                    "type": "boolean"
+                },
+                "last_checked": {
+                    "type": "string"
+                },
+                "parent_url": {
+                    "type": "string"
+                },
+                "queue_time": {
+                    "description": "Time spent waiting for a request slot",
+                    "type": "string"
+                },
+                "redirect_flags": {
+                    "description": "RedirectFlags reports redirect policy problems: permanent,\nhttps_downgrade, cross_domain and long_chain",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "redirects": {
+                    "description": "Redirects lists every hop followed before the final response",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RedirectHop"
+                    }
+                },
+                "referrers": {
+                    "description": "Referrers lists every page linking to URL; ParentURL is the first of them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "resource_type": {
+                    "description": "ResourceType is what referenced the URL: page for anchors, or image,\nscript, stylesheet, iframe or media",
+                    "type": "string"
+                },
+                "response_time": {
+                    "type": "string"
+                },
+                "skip_reason": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "description": "Skipped is set for URLs that were deliberately not requested",
+                    "type": "boolean"
+                },
+                "soft_error": {
+                    "description": "SoftError is set when a 2xx page looks like a not-found page",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SoftError"
+                        }
+                    ]
+                },
+                "sources": {
+                    "description": "Sources lists every place the URL was found, one entry per occurrence",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkSource"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "variants": {
+                    "description": "Variants lists the raw URLs that normalized to URL",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.LinkSummary": {
+            "type": "object",
+            "properties": {
+                "broken": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "working": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Notifier": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "Format defaults to webhook",
+                    "enum": [
+                        "webhook",
+                        "slack",
+                        "teams"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.NotifierFormat"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "nightly"
+                },
+                "paused": {
+                    "description": "Paused keeps the notifier without sending anything",
+                    "type": "boolean"
+                },
+                "rule": {
+                    "description": "Rule defaults to always",
+                    "enum": [
+                        "always",
+                        "broken",
+                        "regression"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.NotifyRule"
+                        }
+                    ]
+                },
+                "secret": {
+                    "description": "Secret signs every payload with HMAC-SHA256 in the X-BLT-Signature-256\nheader; it is never returned",
+                    "type": "string"
+                },
+                "signed": {
+                    "description": "Signed is set when the notifier has a secret",
+                    "type": "boolean"
+                },
+                "sites": {
+                    "description": "Sites limits the notifier to runs of these start URLs; empty means all runs",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "description": "URL receives a POST for every run the rule fires on",
+                    "type": "string",
+                    "example": "https://hooks.slack.com/services/T000/B000/XXXX"
+                }
+            }
+        },
+        "models.NotifierFormat": {
+            "type": "string",
+            "enum": [
+                "webhook",
+                "slack",
+                "teams"
+            ],
+            "x-enum-comments": {
+                "FormatSlack": "A Slack incoming webhook message",
+                "FormatTeams": "A Microsoft Teams adaptive card message",
+                "FormatWebhook": "The Notification as JSON"
+            },
+            "x-enum-varnames": [
+                "FormatWebhook",
+                "FormatSlack",
+                "FormatTeams"
+            ]
+        },
+        "models.NotifierRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "format": {
+                    "description": "Format defaults to webhook",
+                    "enum": [
+                        "webhook",
+                        "slack",
+                        "teams"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.NotifierFormat"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "example": "nightly"
+                },
+                "paused": {
+                    "description": "Paused keeps the notifier without sending anything",
+                    "type": "boolean"
+                },
+                "rule": {
+                    "description": "Rule defaults to always",
+                    "enum": [
+                        "always",
+                        "broken",
+                        "regression"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.NotifyRule"
+                        }
+                    ]
+                },
+                "secret": {
+                    "description": "Secret signs every payload with HMAC-SHA256 in the X-BLT-Signature-256\nheader; it is never returned",
+                    "type": "string"
+                },
+                "sites": {
+                    "description": "Sites limits the notifier to runs of these start URLs; empty means all runs",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "description": "URL receives a POST for every run the rule fires on",
+                    "type": "string",
+                    "example": "https://hooks.slack.com/services/T000/B000/XXXX"
+                }
+            }
+        },
+        "models.NotifyRule": {
+            "type": "string",
+            "enum": [
+                "always",
+                "broken",
+                "regression"
+            ],
+            "x-enum-comments": {
+                "NotifyAlways": "Every finished run",
+                "NotifyBroken": "Runs with at least one broken link",
+                "NotifyRegression": "Runs with links broken since the previous run of the same URL"
+            },
+            "x-enum-varnames": [
+                "NotifyAlways",
+                "NotifyBroken",
+                "NotifyRegression"
+            ]
+        },
+        "models.RedirectHop": {
+            "type": "object",
+            "properties": {
+                "location": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Run": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "description": "Config is the request the crawl was started with",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CheckRequest"
+                        }
+                    ]
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_counts": {
+                    "description": "ErrorCounts counts the broken links per error category",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "results": {
+                    "description": "Results are left out when listing runs",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkStatus"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "$ref": "#/definitions/models.JobState"
+                },
+                "summary": {
+                    "$ref": "#/definitions/models.LinkSummary"
+                },
+                "truncated": {
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RunDeletion": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RunDiff": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string"
+                },
+                "changes": {
+                    "description": "Changes lists every link except those unchanged without a flag",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkChange"
+                    }
+                },
+                "counts": {
+                    "description": "Counts has the number of links of every change kind",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "head": {
+                    "type": "string"
+                },
+                "slower": {
+                    "type": "integer"
+                },
+                "status_changed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Schedule": {
+            "type": "object",
+            "required": [
+                "request"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "cron": {
+                    "description": "Cron is a standard five field cron expression or a descriptor like\n\"@daily\"; exactly one of Cron and Interval is required",
+                    "type": "string",
+                    "example": "0 3 * * *"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "interval": {
+                    "description": "Interval runs the crawl every so often, e.g. \"6h\"; at least a minute",
+                    "type": "string",
+                    "example": "6h"
+                },
+                "last_job_id": {
+                    "type": "string"
+                },
+                "last_run": {
+                    "description": "LastRun is when the last crawl was started",
+                    "type": "string"
+                },
+                "last_state": {
+                    "description": "LastState is the state of the last job; empty once neither the job\nnor its run is kept anymore",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.JobState"
+                        }
+                    ]
+                },
+                "next_run": {
+                    "description": "NextRun is when the next crawl is due; nil while paused",
+                    "type": "string"
+                },
+                "paused": {
+                    "description": "Paused keeps the schedule without starting crawls",
+                    "type": "boolean"
+                },
+                "request": {
+                    "description": "Request is the crawl to run, with the same options as /check-links",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CheckRequest"
+                        }
+                    ]
+                },
+                "skipped": {
+                    "description": "Skipped counts due runs not started because the previous one was still running",
+                    "type": "integer"
+                },
+                "timezone": {
+                    "description": "Timezone the cron expression is evaluated in; defaults to UTC",
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "models.ScheduleRequest": {
+            "type": "object",
+            "required": [
+                "request"
+            ],
+            "properties": {
+                "cron": {
+                    "description": "Cron is a standard five field cron expression or a descriptor like\n\"@daily\"; exactly one of Cron and Interval is required",
+                    "type": "string",
+                    "example": "0 3 * * *"
+                },
+                "interval": {
+                    "description": "Interval runs the crawl every so often, e.g. \"6h\"; at least a minute",
+                    "type": "string",
+                    "example": "6h"
+                },
+                "paused": {
+                    "description": "Paused keeps the schedule without starting crawls",
+                    "type": "boolean"
+                },
+                "request": {
+                    "description": "Request is the crawl to run, with the same options as /check-links",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CheckRequest"
+                        }
+                    ]
+                },
+                "timezone": {
+                    "description": "Timezone the cron expression is evaluated in; defaults to UTC",
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "models.SoftError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SoftErrorRequest": {
+            "type": "object",
+            "properties": {
+                "body_patterns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "min_body_size": {
+                    "description": "MinBodySize flags pages with less HTML than this many bytes; 0 disables it",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "probe": {
+                    "description": "Probe compares pages with the answer to a made-up URL on their host",
+                    "type": "boolean"
+                },
+                "title_patterns": {
+                    "description": "TitlePatterns and BodyPatterns are case-insensitive regular\nexpressions replacing the default not-found patterns",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         }
@@ -124,7 +2229,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost:8080",
-	BasePath:         "/",
+	BasePath:         "/api",
 	Schemes:          []string{},
 	Title:            "Broken Links Tester API",
 	Description:      "API for testing broken links on websites",
@@ -0,0 +1,45 @@
+package models
+
+// LinkChangeKind classifies how a link changed between two runs
+type LinkChangeKind string
+
+const (
+	ChangeNewlyBroken LinkChangeKind = "newly_broken" // Broken now, working or unknown before
+	ChangeFixed       LinkChangeKind = "fixed"        // Working now, broken before
+	ChangeStillBroken LinkChangeKind = "still_broken" // Broken in both runs
+	ChangeDiscovered  LinkChangeKind = "discovered"   // Working now, not found or skipped before
+	ChangeDisappeared LinkChangeKind = "disappeared"  // Only found by the older run
+	ChangeUnchanged   LinkChangeKind = "unchanged"    // Working in both runs
+	ChangeSkipped     LinkChangeKind = "skipped"      // Not requested by the newer run, e.g. disallowed by robots.txt
+)
+
+// LinkChangeKinds lists every change kind
+var LinkChangeKinds = []LinkChangeKind{
+	ChangeNewlyBroken, ChangeFixed, ChangeStillBroken, ChangeDiscovered, ChangeDisappeared, ChangeUnchanged, ChangeSkipped,
+}
+
+// LinkChange is how one link differs between two runs
+type LinkChange struct {
+	URL          string         `json:"url"`
+	ResourceType string         `json:"resource_type,omitempty"`
+	Change       LinkChangeKind `json:"change"`
+	// Before and After are the link in the older and newer run, when it was found
+	Before *LinkStatus `json:"before,omitempty"`
+	After  *LinkStatus `json:"after,omitempty"`
+	// StatusChanged is set when both runs got a response but with different status codes
+	StatusChanged bool `json:"status_changed,omitempty"`
+	// Slower is set when the response time regressed beyond the diff thresholds
+	Slower bool `json:"slower,omitempty"`
+}
+
+// RunDiff compares an older base run with a newer head run
+type RunDiff struct {
+	Base string `json:"base"`
+	Head string `json:"head"`
+	// Counts has the number of links of every change kind
+	Counts        map[LinkChangeKind]int `json:"counts"`
+	StatusChanged int                    `json:"status_changed"`
+	Slower        int                    `json:"slower"`
+	// Changes lists every link except those unchanged without a flag
+	Changes []LinkChange `json:"changes"`
+}
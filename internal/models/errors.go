@@ -0,0 +1,25 @@
+package models
+
+// ErrorCategory classifies why a link is broken
+type ErrorCategory string
+
+const (
+	ErrorDNS               ErrorCategory = "dns"                // The host name does not resolve
+	ErrorTLS               ErrorCategory = "tls"                // Certificate or handshake failure
+	ErrorConnectionRefused ErrorCategory = "connection_refused" // Nothing listens on the port
+	ErrorConnectionReset   ErrorCategory = "connection_reset"   // The server dropped the connection
+	ErrorTimeout           ErrorCategory = "timeout"            // No response in time
+	ErrorRedirectLoop      ErrorCategory = "redirect_loop"      // The redirect chain loops or never ends
+	ErrorHTTPClient        ErrorCategory = "http_4xx"           // The server answered with a 4xx status
+	ErrorHTTPServer        ErrorCategory = "http_5xx"           // The server answered with a 5xx status
+	ErrorBrokenFragment    ErrorCategory = "broken_fragment"    // The page exists but the #fragment does not
+	ErrorSoft404           ErrorCategory = "soft_404"           // A 2xx page that looks like a not-found page
+	ErrorNetwork           ErrorCategory = "network"            // Any other network failure
+	ErrorUnknown           ErrorCategory = "unknown"
+)
+
+// ErrorCategories lists every category in a stable order
+var ErrorCategories = []ErrorCategory{
+	ErrorDNS, ErrorTLS, ErrorConnectionRefused, ErrorConnectionReset, ErrorTimeout, ErrorRedirectLoop,
+	ErrorHTTPClient, ErrorHTTPServer, ErrorBrokenFragment, ErrorSoft404, ErrorNetwork, ErrorUnknown,
+}
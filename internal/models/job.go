@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// JobState is the lifecycle state of an asynchronous crawl job
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobDone      JobState = "done"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// Finished reports whether the job reached a terminal state
+func (s JobState) Finished() bool {
+	return s == JobDone || s == JobFailed || s == JobCancelled
+}
+
+// CrawlProgress holds the live counters of a running crawl
+type CrawlProgress struct {
+	Visited  int `json:"visited"`
+	Queued   int `json:"queued"`
+	InFlight int `json:"in_flight"`
+	Broken   int `json:"broken"`
+}
+
+// Job represents an asynchronous crawl job
+type Job struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+	// Config is the request the job was submitted with
+	Config   *CheckRequest `json:"config,omitempty"`
+	State    JobState      `json:"state"`
+	Progress CrawlProgress `json:"progress"`
+	Results  []LinkStatus  `json:"results,omitempty"`
+	// ErrorCounts counts the broken links recorded so far per error category
+	ErrorCounts map[ErrorCategory]int `json:"error_counts,omitempty"`
+	Truncated   bool                  `json:"truncated,omitempty"`
+	Error       string                `json:"error,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	StartedAt   *time.Time            `json:"started_at,omitempty"`
+	FinishedAt  *time.Time            `json:"finished_at,omitempty"`
+}
@@ -4,18 +4,165 @@ import "time"
 
 // LinkStatus represents the status of a checked link
 type LinkStatus struct {
-	URL           string        `json:"url"`
-	StatusCode    int           `json:"status_code"`
-	Error         string        `json:"error,omitempty"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+	// ErrorCategory classifies broken links; Error keeps the raw message
+	ErrorCategory ErrorCategory `json:"error_category,omitempty" enums:"dns,tls,connection_refused,connection_reset,timeout,redirect_loop,http_4xx,http_5xx,broken_fragment,soft_404,network,unknown"`
 	ResponseTime  string        `json:"response_time"`
+	QueueTime     string        `json:"queue_time,omitempty"` // Time spent waiting for a request slot
 	Depth         int           `json:"depth"`
 	ParentURL     string        `json:"parent_url,omitempty"`
 	IsWorking     bool          `json:"is_working"`
 	LastChecked   time.Time     `json:"last_checked"`
+	// ResourceType is what referenced the URL: page for anchors, or image,
+	// script, stylesheet, iframe or media
+	ResourceType string `json:"resource_type,omitempty"`
+	// CanonicalURL is the <link rel=canonical> target declared by the page
+	CanonicalURL string `json:"canonical_url,omitempty"`
+	// Variants lists the raw URLs that normalized to URL
+	Variants []string `json:"variants,omitempty"`
+	// BrokenFragment is set for links whose #fragment matches no id or
+	// anchor name on the target page; URL then includes the fragment
+	BrokenFragment bool `json:"broken_fragment,omitempty"`
+	// FinalURL is where the redirect chain ended, if URL redirected
+	FinalURL string `json:"final_url,omitempty"`
+	// Redirects lists every hop followed before the final response
+	Redirects []RedirectHop `json:"redirects,omitempty"`
+	// RedirectFlags reports redirect policy problems: permanent,
+	// https_downgrade, cross_domain and long_chain
+	RedirectFlags []string `json:"redirect_flags,omitempty"`
+	// Referrers lists every page linking to URL; ParentURL is the first of them
+	Referrers []string `json:"referrers,omitempty"`
+	// Sources lists every place the URL was found, one entry per occurrence
+	Sources []LinkSource `json:"sources,omitempty"`
+	// Attempts is the history of every try when the URL was retried
+	Attempts []Attempt `json:"attempts,omitempty"`
+	// SoftError is set when a 2xx page looks like a not-found page
+	SoftError *SoftError `json:"soft_error,omitempty"`
+	// Skipped is set for URLs that were deliberately not requested
+	Skipped    bool   `json:"skipped,omitempty"`
+	SkipReason string `json:"skip_reason,omitempty"`
+}
+
+// Attempt is one try at fetching a URL
+type Attempt struct {
+	StatusCode    int           `json:"status_code,omitempty"`
+	Error         string        `json:"error,omitempty"`
+	ErrorCategory ErrorCategory `json:"error_category,omitempty"`
+	Duration      string        `json:"duration"`
+	// Delay is how long the crawler waited before the next attempt
+	Delay string `json:"delay,omitempty"`
+}
+
+// SoftError is the soft-404 verdict for a page and the rule that matched:
+// title_pattern, body_pattern, tiny_body or bogus_url_fingerprint
+type SoftError struct {
+	Rule   string `json:"rule"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SoftErrorRequest turns on and tunes soft-404 detection, which is off
+// unless a request sends it
+type SoftErrorRequest struct {
+	// TitlePatterns and BodyPatterns are case-insensitive regular
+	// expressions replacing the default not-found patterns
+	TitlePatterns []string `json:"title_patterns,omitempty"`
+	BodyPatterns  []string `json:"body_patterns,omitempty"`
+	// Probe compares pages with the answer to a made-up URL on their host
+	Probe *bool `json:"probe,omitempty"`
+	// MinBodySize flags pages with less HTML than this many bytes; 0 disables it
+	MinBodySize *int `json:"min_body_size,omitempty" binding:"omitempty,min=0"`
+}
+
+// RedirectHop is one response of a redirect chain
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+// LinkSource locates one occurrence of a link on the page that contains it
+type LinkSource struct {
+	ParentURL string `json:"parent_url"`
+	// Text is the anchor text, or the alt text of an image
+	Text      string `json:"text,omitempty"`
+	Title     string `json:"title,omitempty"`
+	AriaLabel string `json:"aria_label,omitempty"`
+	// Selector is a CSS selector path to the element
+	Selector string `json:"selector,omitempty" example:"main#content > p:nth-of-type(2) > a:nth-of-type(1)"`
+	// Line and Column locate the tag in the HTML source; only the static fetcher sets them
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+// LinkGraph is the graph of pages linking to checked URLs
+type LinkGraph struct {
+	Nodes []LinkNode `json:"nodes"`
+	Edges []LinkEdge `json:"edges"`
+}
+
+// LinkNode is a checked URL in a link graph
+type LinkNode struct {
+	URL          string `json:"url"`
+	ResourceType string `json:"resource_type,omitempty"`
+	StatusCode   int    `json:"status_code"`
+	IsWorking    bool   `json:"is_working"`
+	Skipped      bool   `json:"skipped,omitempty"`
+}
+
+// LinkEdge records that the Source page links to Target, Count times
+type LinkEdge struct {
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	ResourceType string `json:"resource_type,omitempty"`
+	Count        int    `json:"count"`
 }
 
 // CheckRequest represents the incoming request to check links
 type CheckRequest struct {
 	URL   string `json:"url" binding:"required,url"`
 	Depth int    `json:"depth" binding:"min=0,max=4"`
-} 
\ No newline at end of file
+	// MaxDuration bounds the whole crawl as a Go duration such as "5m";
+	// when it passes the partial results are returned marked as truncated
+	MaxDuration string `json:"max_duration,omitempty" example:"5m"`
+	// Timeout is the per-page load timeout, e.g. "30s"
+	Timeout string `json:"timeout,omitempty" example:"30s"`
+	// Retries is how many times a failed page load is retried
+	Retries *int `json:"retries,omitempty" binding:"omitempty,min=0"`
+	// RetryDelay is the pause before the first retry, e.g. "2s"; it doubles for every further retry
+	RetryDelay string `json:"retry_delay,omitempty" example:"2s"`
+	// RetryStatuses are the response status codes that are retried
+	RetryStatuses []int `json:"retry_statuses,omitempty" binding:"omitempty,dive,min=400,max=599" example:"429,502,503,504"`
+	// Concurrency is how many pages are loaded in parallel
+	Concurrency int `json:"concurrency,omitempty" binding:"omitempty,min=1"`
+	// WaitUntil is the page load state the browser waits for
+	WaitUntil string `json:"wait_until,omitempty" binding:"omitempty,oneof=load domcontentloaded networkidle" enums:"load,domcontentloaded,networkidle"`
+	// Scope limits which pages have their links followed; links leaving
+	// the scope are still checked but not expanded
+	Scope string `json:"scope,omitempty" binding:"omitempty,oneof=all host domain prefix" enums:"all,host,domain,prefix"`
+	// ScopePrefix is the URL prefix for the "prefix" scope, which it requires;
+	// defaults to the URL
+	ScopePrefix string `json:"scope_prefix,omitempty"`
+	// Include keeps only URLs matching one of these globs ("re:" for regexes)
+	Include []string `json:"include,omitempty"`
+	// Exclude drops URLs matching any of these globs ("re:" for regexes)
+	Exclude []string `json:"exclude,omitempty"`
+	// MaxPerHost caps concurrent requests to a single host
+	MaxPerHost int `json:"max_per_host,omitempty" binding:"omitempty,min=1"`
+	// RequestsPerSecond caps the request rate to a single host
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty" binding:"omitempty,gt=0"`
+	// Resources selects the sub-resource types checked besides anchors
+	Resources []string `json:"resources,omitempty" binding:"omitempty,dive,oneof=image script stylesheet iframe media all"`
+	// MaxRedirects flags redirect chains with more hops than this as long_chain
+	MaxRedirects int `json:"max_redirects,omitempty" binding:"omitempty,min=1,max=20"`
+	// SoftErrors enables the detection of 2xx pages that look like not-found pages
+	SoftErrors *SoftErrorRequest `json:"soft_errors,omitempty"`
+	// IgnoreRobots disables robots.txt rules and Crawl-delay, for sites we own
+	IgnoreRobots bool `json:"ignore_robots,omitempty"`
+	// LoadLeaves loads links that won't be followed as full pages instead of
+	// checking them with HEAD, so soft 404s are detected on them too
+	LoadLeaves bool `json:"load_leaves,omitempty"`
+	// Normalize lists the URL normalization rules; empty uses the defaults
+	Normalize []string `json:"normalize,omitempty" binding:"omitempty,dive,oneof=strip_fragment sort_query drop_tracking lowercase_host remove_default_port remove_trailing_slash honor_canonical none"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// NotifyRule decides which finished runs a notifier is told about
+type NotifyRule string
+
+const (
+	NotifyAlways     NotifyRule = "always"     // Every finished run
+	NotifyBroken     NotifyRule = "broken"     // Runs with at least one broken link
+	NotifyRegression NotifyRule = "regression" // Runs with links broken since the previous run of the same URL
+)
+
+// NotifierFormat is the payload shape a notifier sends
+type NotifierFormat string
+
+const (
+	FormatWebhook NotifierFormat = "webhook" // The Notification as JSON
+	FormatSlack   NotifierFormat = "slack"   // A Slack incoming webhook message
+	FormatTeams   NotifierFormat = "teams"   // A Microsoft Teams adaptive card message
+)
+
+// NotifierRequest creates or replaces a notifier
+type NotifierRequest struct {
+	Name string `json:"name,omitempty" example:"nightly"`
+	// URL receives a POST for every run the rule fires on
+	URL string `json:"url" binding:"required,url" example:"https://hooks.slack.com/services/T000/B000/XXXX"`
+	// Format defaults to webhook
+	Format NotifierFormat `json:"format,omitempty" binding:"omitempty,oneof=webhook slack teams" enums:"webhook,slack,teams"`
+	// Rule defaults to always
+	Rule NotifyRule `json:"rule,omitempty" binding:"omitempty,oneof=always broken regression" enums:"always,broken,regression"`
+	// Secret signs every payload with HMAC-SHA256 in the X-BLT-Signature-256
+	// header; it is never returned
+	Secret string `json:"secret,omitempty"`
+	// Sites limits the notifier to runs of these start URLs; empty means all runs
+	Sites []string `json:"sites,omitempty" binding:"omitempty,dive,url"`
+	// Paused keeps the notifier without sending anything
+	Paused bool `json:"paused,omitempty"`
+}
+
+// Notifier sends finished runs to a webhook
+type Notifier struct {
+	ID string `json:"id"`
+	NotifierRequest
+	// Signed is set when the notifier has a secret
+	Signed    bool      `json:"signed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery is one notification sent to a notifier, with every attempt
+type Delivery struct {
+	ID         string     `json:"id"`
+	NotifierID string     `json:"notifier_id"`
+	RunID      string     `json:"run_id"`
+	URL        string     `json:"url"`
+	Rule       NotifyRule `json:"rule"`
+	State      string     `json:"state" enums:"pending,delivered,failed"`
+	// StatusCode is the response status of the last attempt
+	StatusCode  int        `json:"status_code,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Attempts    []Attempt  `json:"attempts,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+// Notification is the payload of generic webhooks
+type Notification struct {
+	// Event is always "run.finished"
+	Event string `json:"event" example:"run.finished"`
+	// Run is the finished run without its results
+	Run Run `json:"run"`
+	// Previous is the ID of the last complete run of the same URL, which
+	// the changes are counted against
+	Previous string `json:"previous,omitempty"`
+	// Changes counts the links of every change kind since the previous run
+	Changes map[LinkChangeKind]int `json:"changes,omitempty"`
+	// NewlyBroken are the links broken since the previous run
+	NewlyBroken []LinkStatus `json:"newly_broken,omitempty"`
+	// Broken are the broken links of the run, at most 50 of them
+	Broken []LinkStatus `json:"broken,omitempty"`
+}
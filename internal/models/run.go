@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// LinkSummary counts checked links by outcome
+type LinkSummary struct {
+	Total   int `json:"total"`
+	Working int `json:"working"`
+	Broken  int `json:"broken"`
+	Skipped int `json:"skipped"`
+}
+
+// Summarize counts the links by outcome
+func Summarize(links []LinkStatus) LinkSummary {
+	s := LinkSummary{Total: len(links)}
+	for _, l := range links {
+		switch {
+		case l.Skipped:
+			s.Skipped++
+		case l.IsWorking:
+			s.Working++
+		default:
+			s.Broken++
+		}
+	}
+	return s
+}
+
+// Run is a finished crawl kept in the run store
+type Run struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+	// Config is the request the crawl was started with
+	Config  CheckRequest `json:"config"`
+	State   JobState     `json:"state"`
+	Summary LinkSummary  `json:"summary"`
+	// ErrorCounts counts the broken links per error category
+	ErrorCounts map[ErrorCategory]int `json:"error_counts,omitempty"`
+	Truncated   bool                  `json:"truncated,omitempty"`
+	Error       string                `json:"error,omitempty"`
+	StartedAt   time.Time             `json:"started_at"`
+	FinishedAt  time.Time             `json:"finished_at"`
+	// Results are left out when listing runs
+	Results []LinkStatus `json:"results,omitempty"`
+}
+
+// RunDeletion reports how many runs a cleanup removed
+type RunDeletion struct {
+	Deleted int `json:"deleted"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ScheduleRequest creates or replaces a recurring crawl
+type ScheduleRequest struct {
+	// Request is the crawl to run, with the same options as /check-links
+	Request CheckRequest `json:"request" binding:"required"`
+	// Cron is a standard five field cron expression or a descriptor like
+	// "@daily"; exactly one of Cron and Interval is required
+	Cron string `json:"cron,omitempty" example:"0 3 * * *"`
+	// Interval runs the crawl every so often, e.g. "6h"; at least a minute
+	Interval string `json:"interval,omitempty" example:"6h"`
+	// Timezone the cron expression is evaluated in; defaults to UTC
+	Timezone string `json:"timezone,omitempty" example:"Europe/Berlin"`
+	// Paused keeps the schedule without starting crawls
+	Paused bool `json:"paused,omitempty"`
+}
+
+// Schedule is a recurring crawl and the state of its runs
+type Schedule struct {
+	ID string `json:"id"`
+	ScheduleRequest
+	CreatedAt time.Time `json:"created_at"`
+	// NextRun is when the next crawl is due; nil while paused
+	NextRun *time.Time `json:"next_run,omitempty"`
+	// LastRun is when the last crawl was started
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastJobID string     `json:"last_job_id,omitempty"`
+	// LastState is the state of the last job; empty once neither the job
+	// nor its run is kept anymore
+	LastState JobState `json:"last_state,omitempty"`
+	// Skipped counts due runs not started because the previous one was still running
+	Skipped int `json:"skipped,omitempty"`
+}
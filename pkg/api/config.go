@@ -0,0 +1,277 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/aocamilo/broken-links-tester/pkg/store"
+)
+
+// Config holds the server settings
+type Config struct {
+	// Fetcher selects the crawler backend: "playwright" (default) or "http"
+	Fetcher string
+	// Limits caps the crawl options a request may ask for
+	Limits Limits
+	// RunsPath is the database file finished runs are kept in; empty keeps
+	// them in memory until the server stops
+	RunsPath string
+	// Retention decides how long finished runs are kept
+	Retention store.Retention
+}
+
+// Limits are the server-side maximums for per-request crawl options; a zero
+// limit leaves the option unlimited
+type Limits struct {
+	MaxTimeout     time.Duration
+	MaxRetries     int
+	MaxRetryDelay  time.Duration
+	MaxConcurrency int
+	// MaxRequestsPerSecond caps the per-host request rate
+	MaxRequestsPerSecond float64
+	// MaxDuration also applies to requests that don't set max_duration
+	MaxDuration time.Duration
+}
+
+// DefaultLimits returns the default request limits
+func DefaultLimits() Limits {
+	return Limits{
+		MaxTimeout:           2 * time.Minute,
+		MaxRetries:           5,
+		MaxRetryDelay:        30 * time.Second,
+		MaxConcurrency:       10,
+		MaxRequestsPerSecond: 20,
+		MaxDuration:          30 * time.Minute,
+	}
+}
+
+// DefaultConfig returns the default server settings
+func DefaultConfig() Config {
+	return Config{
+		Fetcher:   crawler.BackendPlaywright,
+		Limits:    DefaultLimits(),
+		Retention: store.DefaultRetention(),
+	}
+}
+
+// ConfigFromEnv returns the default settings overridden by environment variables:
+// CRAWLER_FETCHER, MAX_PAGE_TIMEOUT, MAX_RETRIES, MAX_RETRY_DELAY,
+// MAX_CONCURRENCY, MAX_REQUESTS_PER_SECOND, MAX_CRAWL_DURATION, RUNS_DB,
+// RUN_RETENTION and MAX_RUNS. Zero means unlimited, or keeping runs forever
+// for the retention settings; negative values are rejected.
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	if v := os.Getenv("CRAWLER_FETCHER"); v != "" {
+		cfg.Fetcher = v
+	}
+	cfg.RunsPath = os.Getenv("RUNS_DB")
+
+	durations := map[string]*time.Duration{
+		"MAX_PAGE_TIMEOUT":   &cfg.Limits.MaxTimeout,
+		"MAX_RETRY_DELAY":    &cfg.Limits.MaxRetryDelay,
+		"MAX_CRAWL_DURATION": &cfg.Limits.MaxDuration,
+		"RUN_RETENTION":      &cfg.Retention.MaxAge,
+	}
+	for name, dst := range durations {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid %s: %v", name, err)
+			}
+			if d < 0 {
+				return cfg, fmt.Errorf("invalid %s: %q is negative", name, v)
+			}
+			*dst = d
+		}
+	}
+
+	ints := map[string]*int{
+		"MAX_RETRIES":     &cfg.Limits.MaxRetries,
+		"MAX_CONCURRENCY": &cfg.Limits.MaxConcurrency,
+		"MAX_RUNS":        &cfg.Retention.MaxRuns,
+	}
+	for name, dst := range ints {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid %s: %v", name, err)
+			}
+			if n < 0 {
+				return cfg, fmt.Errorf("invalid %s: %q is negative", name, v)
+			}
+			*dst = n
+		}
+	}
+
+	if v := os.Getenv("MAX_REQUESTS_PER_SECOND"); v != "" {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil || rps < 0 {
+			return cfg, fmt.Errorf("invalid MAX_REQUESTS_PER_SECOND: %q", v)
+		}
+		cfg.Limits.MaxRequestsPerSecond = rps
+	}
+
+	return cfg, nil
+}
+
+// crawlOptions builds the crawl options for a check request and rejects
+// values above the server limits
+func (s *Server) crawlOptions(req models.CheckRequest) (crawler.CrawlOptions, error) {
+	opts := clampDefaults(crawler.DefaultCrawlOptions(), s.limits)
+	limits := s.limits
+
+	var err error
+	if opts.MaxDuration, err = parseDuration("max_duration", req.MaxDuration, limits.MaxDuration); err != nil {
+		return opts, err
+	}
+	if opts.MaxDuration == 0 {
+		opts.MaxDuration = limits.MaxDuration
+	}
+
+	if req.Timeout != "" {
+		if opts.Browser.Timeout, err = parseDuration("timeout", req.Timeout, limits.MaxTimeout); err != nil {
+			return opts, err
+		}
+	}
+
+	if req.Retries != nil {
+		if limits.MaxRetries > 0 && *req.Retries > limits.MaxRetries {
+			return opts, fmt.Errorf("retries must be at most %d", limits.MaxRetries)
+		}
+		// MaxAttempts counts the first attempt as well
+		opts.Retry.MaxAttempts = *req.Retries + 1
+	}
+
+	if req.RetryDelay != "" {
+		if opts.Retry.BaseDelay, err = parseDuration("retry_delay", req.RetryDelay, limits.MaxRetryDelay); err != nil {
+			return opts, err
+		}
+	}
+	// No single delay may exceed the limit, however often it doubled
+	if limits.MaxRetryDelay > 0 {
+		opts.Retry.MaxDelay = min(opts.Retry.MaxDelay, limits.MaxRetryDelay)
+	}
+
+	if len(req.RetryStatuses) > 0 {
+		opts.Retry.RetryStatuses = req.RetryStatuses
+	}
+
+	if req.Concurrency > 0 {
+		if limits.MaxConcurrency > 0 && req.Concurrency > limits.MaxConcurrency {
+			return opts, fmt.Errorf("concurrency must be at most %d", limits.MaxConcurrency)
+		}
+		opts.Browser.MaxConcurrent = req.Concurrency
+	}
+
+	if req.WaitUntil != "" {
+		opts.Browser.WaitUntil = req.WaitUntil
+	}
+
+	if req.MaxPerHost > 0 {
+		if req.MaxPerHost > opts.Browser.MaxConcurrent {
+			return opts, fmt.Errorf("max_per_host must be at most concurrency (%d)", opts.Browser.MaxConcurrent)
+		}
+		opts.Politeness.MaxPerHost = req.MaxPerHost
+	}
+
+	if req.RequestsPerSecond > 0 {
+		if limits.MaxRequestsPerSecond > 0 && req.RequestsPerSecond > limits.MaxRequestsPerSecond {
+			return opts, fmt.Errorf("requests_per_second must be at most %g", limits.MaxRequestsPerSecond)
+		}
+		opts.Politeness.RequestsPerSecond = req.RequestsPerSecond
+		opts.Politeness.Burst = max(int(req.RequestsPerSecond), 1)
+	}
+
+	if req.MaxRedirects > 0 {
+		opts.MaxRedirects = req.MaxRedirects
+	}
+
+	if soft := req.SoftErrors; soft != nil {
+		opts.SoftErrors.Enabled = true
+		if len(soft.TitlePatterns) > 0 {
+			if opts.SoftErrors.TitlePatterns, err = crawler.SoftErrorPatterns(soft.TitlePatterns); err != nil {
+				return opts, err
+			}
+		}
+		if len(soft.BodyPatterns) > 0 {
+			if opts.SoftErrors.BodyPatterns, err = crawler.SoftErrorPatterns(soft.BodyPatterns); err != nil {
+				return opts, err
+			}
+		}
+		if soft.Probe != nil {
+			opts.SoftErrors.ProbeBogusURL = *soft.Probe
+		}
+		if soft.MinBodySize != nil {
+			opts.SoftErrors.MinBodySize = *soft.MinBodySize
+		}
+	}
+
+	if req.ScopePrefix != "" && req.Scope != crawler.ScopePrefix {
+		return opts, fmt.Errorf("scope_prefix requires scope %q", crawler.ScopePrefix)
+	}
+	if req.Scope != "" {
+		opts.Scope = crawler.Scope{Mode: req.Scope, Prefix: req.ScopePrefix}
+	}
+
+	opts.IgnoreRobots = req.IgnoreRobots
+	opts.HeadFirst = !req.LoadLeaves
+
+	if opts.Resources, err = crawler.ResourceTypes(req.Resources); err != nil {
+		return opts, err
+	}
+
+	if opts.Normalize, err = crawler.NormalizeRules(req.Normalize); err != nil {
+		return opts, err
+	}
+
+	if len(req.Include) > 0 || len(req.Exclude) > 0 {
+		if opts.Filter, err = crawler.NewURLFilter(req.Include, req.Exclude); err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
+
+// clampDefaults lowers the default crawl options to the server limits, so
+// the limits also hold for requests that leave the options unset
+func clampDefaults(opts crawler.CrawlOptions, limits Limits) crawler.CrawlOptions {
+	if limits.MaxTimeout > 0 {
+		opts.Browser.Timeout = min(opts.Browser.Timeout, limits.MaxTimeout)
+	}
+	if limits.MaxRetries > 0 {
+		opts.Retry.MaxAttempts = min(opts.Retry.MaxAttempts, limits.MaxRetries+1)
+	}
+	if limits.MaxRetryDelay > 0 {
+		opts.Retry.BaseDelay = min(opts.Retry.BaseDelay, limits.MaxRetryDelay)
+	}
+	if limits.MaxConcurrency > 0 {
+		opts.Browser.MaxConcurrent = min(opts.Browser.MaxConcurrent, limits.MaxConcurrency)
+		opts.Politeness.MaxPerHost = min(opts.Politeness.MaxPerHost, opts.Browser.MaxConcurrent)
+	}
+	if limits.MaxRequestsPerSecond > 0 && opts.Politeness.RequestsPerSecond > limits.MaxRequestsPerSecond {
+		opts.Politeness.RequestsPerSecond = limits.MaxRequestsPerSecond
+		opts.Politeness.Burst = min(opts.Politeness.Burst, max(int(limits.MaxRequestsPerSecond), 1))
+	}
+	return opts
+}
+
+// parseDuration parses an optional positive duration no larger than limit;
+// an empty value yields zero and a zero limit means unlimited
+func parseDuration(name, value string, limit time.Duration) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	if limit > 0 && d > limit {
+		return 0, fmt.Errorf("%s must be at most %s", name, limit)
+	}
+	return d, nil
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+)
+
+func TestCrawlOptionsClampDefaults(t *testing.T) {
+	defaults := crawler.DefaultCrawlOptions()
+	tests := []struct {
+		name   string
+		limits Limits
+		check  func(t *testing.T, opts crawler.CrawlOptions)
+	}{
+		{"defaults within limits", DefaultLimits(), func(t *testing.T, opts crawler.CrawlOptions) {
+			if opts.Browser.Timeout != defaults.Browser.Timeout || opts.Browser.MaxConcurrent != defaults.Browser.MaxConcurrent ||
+				opts.Retry.MaxAttempts != defaults.Retry.MaxAttempts || opts.Politeness != defaults.Politeness {
+				t.Errorf("defaults changed: %+v", opts)
+			}
+		}},
+		{"timeout", Limits{MaxTimeout: 30 * time.Second}, func(t *testing.T, opts crawler.CrawlOptions) {
+			if opts.Browser.Timeout != 30*time.Second {
+				t.Errorf("timeout = %s, want 30s", opts.Browser.Timeout)
+			}
+		}},
+		{"concurrency", Limits{MaxConcurrency: 2}, func(t *testing.T, opts crawler.CrawlOptions) {
+			if opts.Browser.MaxConcurrent != 2 || opts.Politeness.MaxPerHost != 2 {
+				t.Errorf("concurrency = %d, per host = %d, want 2 and 2", opts.Browser.MaxConcurrent, opts.Politeness.MaxPerHost)
+			}
+		}},
+		{"retries", Limits{MaxRetries: 1, MaxRetryDelay: time.Second}, func(t *testing.T, opts crawler.CrawlOptions) {
+			if opts.Retry.MaxAttempts != 2 || opts.Retry.BaseDelay != time.Second || opts.Retry.MaxDelay != time.Second {
+				t.Errorf("retry = %+v, want 2 attempts and 1s delays", opts.Retry)
+			}
+		}},
+		{"request rate", Limits{MaxRequestsPerSecond: 2}, func(t *testing.T, opts crawler.CrawlOptions) {
+			if opts.Politeness.RequestsPerSecond != 2 || opts.Politeness.Burst != 2 {
+				t.Errorf("rate = %g, burst = %d, want 2 and 2", opts.Politeness.RequestsPerSecond, opts.Politeness.Burst)
+			}
+		}},
+		{"no limits", Limits{}, func(t *testing.T, opts crawler.CrawlOptions) {
+			if opts.Browser.Timeout != defaults.Browser.Timeout || opts.Browser.MaxConcurrent != defaults.Browser.MaxConcurrent {
+				t.Errorf("defaults changed without limits: %+v", opts.Browser)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{limits: tt.limits}
+			opts, err := s.crawlOptions(models.CheckRequest{URL: "https://example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, opts)
+		})
+	}
+}
+
+func TestCrawlOptionsRejectValuesAboveLimits(t *testing.T) {
+	retries := 9
+	tests := []struct {
+		name string
+		req  models.CheckRequest
+	}{
+		{"timeout", models.CheckRequest{Timeout: "5m"}},
+		{"retries", models.CheckRequest{Retries: &retries}},
+		{"concurrency", models.CheckRequest{Concurrency: 50}},
+		{"requests per second", models.CheckRequest{RequestsPerSecond: 100}},
+		{"max duration", models.CheckRequest{MaxDuration: "2h"}},
+		{"scope prefix without prefix scope", models.CheckRequest{Scope: "host", ScopePrefix: "https://example.com/docs"}},
+		{"scope prefix without scope", models.CheckRequest{ScopePrefix: "https://example.com/docs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{limits: DefaultLimits()}
+			if _, err := s.crawlOptions(tt.req); err == nil {
+				t.Error("crawlOptions() accepted a value above the limit")
+			}
+		})
+	}
+}
+
+func TestCrawlOptionsSoftErrors(t *testing.T) {
+	probe := false
+	tests := []struct {
+		name        string
+		soft        *models.SoftErrorRequest
+		wantEnabled bool
+		wantProbe   bool
+	}{
+		{"not sent", nil, false, true},
+		{"empty", &models.SoftErrorRequest{}, true, true},
+		{"tuned", &models.SoftErrorRequest{Probe: &probe}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{limits: DefaultLimits()}
+			opts, err := s.crawlOptions(models.CheckRequest{URL: "https://example.com", SoftErrors: tt.soft})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.SoftErrors.Enabled != tt.wantEnabled || opts.SoftErrors.ProbeBogusURL != tt.wantProbe {
+				t.Errorf("enabled = %v, probe = %v, want %v and %v",
+					opts.SoftErrors.Enabled, opts.SoftErrors.ProbeBogusURL, tt.wantEnabled, tt.wantProbe)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("MAX_REQUESTS_PER_SECOND", "2.5")
+	t.Setenv("MAX_CONCURRENCY", "3")
+	t.Setenv("MAX_PAGE_TIMEOUT", "15s")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Limits.MaxRequestsPerSecond != 2.5 || cfg.Limits.MaxConcurrency != 3 || cfg.Limits.MaxTimeout != 15*time.Second {
+		t.Errorf("unexpected limits %+v", cfg.Limits)
+	}
+
+	t.Setenv("MAX_REQUESTS_PER_SECOND", "fast")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("ConfigFromEnv() accepted an invalid MAX_REQUESTS_PER_SECOND")
+	}
+}
+
+func TestConfigFromEnvLimits(t *testing.T) {
+	tests := []struct {
+		name, value string
+		wantErr     bool
+	}{
+		{"MAX_RETRIES", "0", false},
+		{"MAX_RETRIES", "-1", true},
+		{"MAX_CONCURRENCY", "0", false},
+		{"MAX_CONCURRENCY", "-2", true},
+		{"MAX_RUNS", "0", false},
+		{"MAX_RUNS", "-1", true},
+		{"MAX_PAGE_TIMEOUT", "0", false},
+		{"MAX_PAGE_TIMEOUT", "-1s", true},
+		{"RUN_RETENTION", "-1h", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			if _, err := ConfigFromEnv(); (err != nil) != tt.wantErr {
+				t.Errorf("ConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/aocamilo/broken-links-tester/pkg/export"
+	"github.com/aocamilo/broken-links-tester/pkg/jobs"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Start an asynchronous link check
+// @Description Queues a crawl and returns the job immediately; poll the job for progress and results
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param request body models.CheckRequest true "URL and depth parameters"
+// @Success 202 {object} models.Job
+// @Failure 400 {object} map[string]string
+// @Router /jobs [post]
+func (s *Server) createJob(c *gin.Context) {
+	var req models.CheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := s.crawlOptions(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job := s.jobs.Submit(req, opts)
+	log.Printf("Queued job %s for URL: %s", job.ID, req.URL)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// @Summary Get a crawl job
+// @Description Returns the state, progress counters, broken link counts per error category and the results of a job
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Produce application/xml
+// @Produce application/sarif+json
+// @Produce text/csv
+// @Produce text/markdown
+// @Produce text/html
+// @Param category query []string false "Only return results in these error categories" collectionFormat(csv) Enums(dns,tls,connection_refused,connection_reset,timeout,redirect_loop,http_4xx,http_5xx,broken_fragment,soft_404,network,unknown)
+// @Param format query string false "Export the results in this format instead of returning the job; the Accept header is used when omitted" Enums(json,junit,sarif,csv,markdown,html)
+// @Success 200 {object} models.Job
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /jobs/{id} [get]
+func (s *Server) getJob(c *gin.Context) {
+	categories, err := categoryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	e, err := exporter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := s.jobs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	job.Results = filterByCategory(job.Results, categories)
+	if e != nil {
+		generated := time.Now()
+		if job.FinishedAt != nil {
+			generated = *job.FinishedAt
+		}
+		writeReport(c, e, export.Report{
+			URL:         job.URL,
+			Links:       job.Results,
+			Truncated:   job.Truncated,
+			GeneratedAt: generated,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// @Summary Get the link graph of a crawl job
+// @Description Returns every checked URL of a finished job as a node and every page linking to it as an edge, with the number of occurrences. Pass target to only get the referrers of one URL.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param target query string false "Only return edges pointing at this URL"
+// @Success 200 {object} models.LinkGraph
+// @Failure 404 {object} map[string]string
+// @Router /jobs/{id}/graph [get]
+func (s *Server) jobGraph(c *gin.Context) {
+	job, err := s.jobs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	graph := crawler.BuildGraph(job.Results)
+	if target := c.Query("target"); target != "" {
+		graph = crawler.Subgraph(graph, target)
+	}
+	c.JSON(http.StatusOK, graph)
+}
+
+// progressInterval is how often progress events are sent on a job stream
+const progressInterval = time.Second
+
+// @Summary Stream crawl job updates
+// @Description Streams Server-Sent Events for a job: a "result" event per checked link (replaying those already recorded), a "progress" event every second and a final "done" event with the job state
+// @Tags jobs
+// @Produce text/event-stream
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.LinkStatus "result events"
+// @Failure 404 {object} map[string]string
+// @Router /jobs/{id}/events [get]
+func (s *Server) streamJob(c *gin.Context) {
+	id := c.Param("id")
+	replay, events, unsubscribe, err := s.jobs.Subscribe(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	for _, status := range replay {
+		c.SSEvent(jobs.EventResult, status)
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(ev.Name, ev.Data)
+			return true
+		case <-ticker.C:
+			if job, err := s.jobs.Get(id); err == nil {
+				c.SSEvent(jobs.EventProgress, job.Progress)
+			}
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// @Summary Cancel a crawl job
+// @Description Cancels a queued or running job
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.Job
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /jobs/{id} [delete]
+func (s *Server) cancelJob(c *gin.Context) {
+	job, err := s.jobs.Cancel(c.Param("id"))
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, jobs.ErrFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, job)
+	}
+}
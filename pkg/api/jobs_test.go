@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/aocamilo/broken-links-tester/pkg/jobs"
+	"github.com/gin-gonic/gin"
+)
+
+// feedRunner reports every status sent on feed as a result and returns
+// them all once feed is closed, or as truncated once ctx is cancelled
+type feedRunner struct {
+	feed chan models.LinkStatus
+}
+
+func (r feedRunner) CheckLinksWithOptions(ctx context.Context, baseURL string, maxDepth int, opts crawler.CrawlOptions) crawler.Result {
+	var result crawler.Result
+	for {
+		select {
+		case status, ok := <-r.feed:
+			if !ok {
+				return result
+			}
+			opts.OnResult(status)
+			result.Links = append(result.Links, status)
+		case <-ctx.Done():
+			result.Truncated = true
+			return result
+		}
+	}
+}
+
+// sseEvent is one event read from a text/event-stream body
+type sseEvent struct {
+	name string
+	data string
+}
+
+func readEvents(t *testing.T, resp *http.Response) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var ev sseEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if ev.name != "" {
+				events = append(events, ev)
+			}
+			ev = sseEvent{}
+		case strings.HasPrefix(line, "event:"):
+			ev.name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			ev.data = strings.TrimPrefix(line, "data:")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestStreamJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	runner := feedRunner{feed: make(chan models.LinkStatus)}
+	s := &Server{jobs: jobs.NewRegistry(runner, 1)}
+	router := gin.New()
+	router.GET("/api/jobs/:id/events", s.streamJob)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	job := s.jobs.Submit(models.CheckRequest{URL: "https://example.com/", Depth: 1}, crawler.DefaultCrawlOptions())
+	runner.feed <- models.LinkStatus{URL: "https://example.com/a", StatusCode: 200, IsWorking: true}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if j, _ := s.jobs.Get(job.ID); len(j.Results) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first result not recorded")
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/api/jobs/" + job.ID + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	runner.feed <- models.LinkStatus{URL: "https://example.com/b", StatusCode: 404}
+	close(runner.feed)
+
+	var names, urls []string
+	var done models.Job
+	for _, ev := range readEvents(t, resp) {
+		switch ev.name {
+		case jobs.EventProgress:
+			continue
+		case jobs.EventResult:
+			var status models.LinkStatus
+			if err := json.Unmarshal([]byte(ev.data), &status); err != nil {
+				t.Fatalf("result event %q: %v", ev.data, err)
+			}
+			urls = append(urls, status.URL)
+		case jobs.EventDone:
+			if err := json.Unmarshal([]byte(ev.data), &done); err != nil {
+				t.Fatalf("done event %q: %v", ev.data, err)
+			}
+		}
+		names = append(names, ev.name)
+	}
+
+	want := []string{jobs.EventResult, jobs.EventResult, jobs.EventDone}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", names, want)
+	}
+	if strings.Join(urls, ",") != "https://example.com/a,https://example.com/b" {
+		t.Errorf("results = %v, want the replayed one then the streamed one", urls)
+	}
+	if done.ID != job.ID || done.State != models.JobDone {
+		t.Errorf("done event = %+v, want job %s done", done, job.ID)
+	}
+}
+
+func TestStreamUnknownJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{jobs: jobs.NewRegistry(feedRunner{}, 1)}
+	router := gin.New()
+	router.GET("/api/jobs/:id/events", s.streamJob)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/jobs/missing/events", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Create a notifier
+// @Description Posts every finished run the rule fires on to a webhook: always, when the run found broken links, or when links broke since the previous run of the same URL. Payloads are generic JSON, Slack messages or Teams adaptive cards, signed with HMAC-SHA256 when a secret is set.
+// @Tags notifiers
+// @Accept json
+// @Produce json
+// @Param request body models.NotifierRequest true "Webhook URL, format and rule"
+// @Success 201 {object} models.Notifier
+// @Failure 400 {object} map[string]string
+// @Router /notifiers [post]
+func (s *Server) createNotifier(c *gin.Context) {
+	var req models.NotifierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	n := s.notifiers.Create(req)
+	log.Printf("Created %s notifier %s with rule %s", n.Format, n.ID, n.Rule)
+	c.JSON(http.StatusCreated, n)
+}
+
+// @Summary List notifiers
+// @Description Returns every notifier, oldest first; secrets are never returned
+// @Tags notifiers
+// @Produce json
+// @Success 200 {object} []models.Notifier
+// @Router /notifiers [get]
+func (s *Server) listNotifiers(c *gin.Context) {
+	c.JSON(http.StatusOK, s.notifiers.List())
+}
+
+// @Summary Get a notifier
+// @Tags notifiers
+// @Produce json
+// @Param id path string true "Notifier ID"
+// @Success 200 {object} models.Notifier
+// @Failure 404 {object} map[string]string
+// @Router /notifiers/{id} [get]
+func (s *Server) getNotifier(c *gin.Context) {
+	n, err := s.notifiers.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, n)
+}
+
+// @Summary Replace a notifier
+// @Description Replaces the settings of a notifier; an empty secret keeps the current one
+// @Tags notifiers
+// @Accept json
+// @Produce json
+// @Param id path string true "Notifier ID"
+// @Param request body models.NotifierRequest true "Webhook URL, format and rule"
+// @Success 200 {object} models.Notifier
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /notifiers/{id} [put]
+func (s *Server) updateNotifier(c *gin.Context) {
+	var req models.NotifierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	n, err := s.notifiers.Update(c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, n)
+}
+
+// @Summary Delete a notifier
+// @Description Deletes a notifier; deliveries already started are still sent
+// @Tags notifiers
+// @Param id path string true "Notifier ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /notifiers/{id} [delete]
+func (s *Server) deleteNotifier(c *gin.Context) {
+	if err := s.notifiers.Delete(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List the deliveries of a notifier
+// @Description Returns the logged deliveries of a notifier with every attempt, most recent first. Only the last 500 deliveries of all notifiers are kept.
+// @Tags notifiers
+// @Produce json
+// @Param id path string true "Notifier ID"
+// @Success 200 {object} []models.Delivery
+// @Failure 404 {object} map[string]string
+// @Router /notifiers/{id}/deliveries [get]
+func (s *Server) notifierDeliveries(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := s.notifiers.Get(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, s.notifiers.Deliveries(id))
+}
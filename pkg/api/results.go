@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/export"
+	"github.com/gin-gonic/gin"
+)
+
+// categoryFilter parses the category query parameter, given as a comma
+// separated list or repeated; no categories means no filtering
+func categoryFilter(c *gin.Context) ([]models.ErrorCategory, error) {
+	var categories []models.ErrorCategory
+	for _, value := range c.QueryArray("category") {
+		for _, name := range strings.Split(value, ",") {
+			category := models.ErrorCategory(strings.TrimSpace(name))
+			if category == "" {
+				continue
+			}
+			if !slices.Contains(models.ErrorCategories, category) {
+				return nil, fmt.Errorf("unknown error category %q", category)
+			}
+			categories = append(categories, category)
+		}
+	}
+	return categories, nil
+}
+
+// filterByCategory keeps the results in one of the categories
+func filterByCategory(results []models.LinkStatus, categories []models.ErrorCategory) []models.LinkStatus {
+	if len(categories) == 0 {
+		return results
+	}
+	filtered := make([]models.LinkStatus, 0)
+	for _, r := range results {
+		if slices.Contains(categories, r.ErrorCategory) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// exporter picks the report format from the format query parameter or
+// else the Accept header; nil means the regular JSON response
+func exporter(c *gin.Context) (export.Exporter, error) {
+	format := c.Query("format")
+	if format == "" {
+		format = export.Negotiate(c.GetHeader("Accept"))
+	}
+	if format == "" || format == export.FormatJSON {
+		return nil, nil
+	}
+	return export.Get(format)
+}
+
+// writeReport responds with the report in the exporter's format
+func writeReport(c *gin.Context, e export.Exporter, report export.Report) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", e.ContentType()+"; charset=utf-8")
+	if err := e.Export(c.Writer, report); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/aocamilo/broken-links-tester/pkg/export"
+	"github.com/aocamilo/broken-links-tester/pkg/store"
+	"github.com/gin-gonic/gin"
+)
+
+// saveRun stores a finished crawl with its link graph, applies the
+// retention policy and notifies the notifiers; failures are logged since
+// the crawl itself succeeded
+func (s *Server) saveRun(run models.Run) (models.Run, error) {
+	run.Summary = models.Summarize(run.Results)
+	run.ErrorCounts = crawler.CountCategories(run.Results)
+	if err := s.runs.Save(&run, crawler.BuildGraph(run.Results)); err != nil {
+		log.Printf("Failed to save run for %s: %v", run.URL, err)
+		return run, err
+	}
+	if _, err := store.Prune(s.runs, s.retention, time.Now()); err != nil {
+		log.Printf("Failed to prune runs: %v", err)
+	}
+	s.notifiers.Notify(run, s.previousRun)
+	return run, nil
+}
+
+// previousRun returns the last complete run of the same URL that finished
+// before run. Failed, cancelled and truncated runs are passed over since
+// links they never reached would all look newly broken.
+func (s *Server) previousRun(run models.Run) (models.Run, bool) {
+	runs, err := s.runs.List(store.Filter{URL: run.URL, Before: run.FinishedAt})
+	if err != nil {
+		log.Printf("Failed to list runs of %s: %v", run.URL, err)
+		return models.Run{}, false
+	}
+	for _, r := range runs {
+		if r.State != models.JobDone || r.Truncated {
+			continue
+		}
+		prev, err := s.runs.Get(r.ID)
+		if err != nil {
+			log.Printf("Failed to load run %s: %v", r.ID, err)
+			return models.Run{}, false
+		}
+		return prev, true
+	}
+	return models.Run{}, false
+}
+
+// saveJob keeps a job that ended as a run under the same ID
+func (s *Server) saveJob(job models.Job) {
+	run := models.Run{
+		ID:        job.ID,
+		URL:       job.URL,
+		Depth:     job.Depth,
+		State:     job.State,
+		Truncated: job.Truncated,
+		Error:     job.Error,
+		Results:   job.Results,
+	}
+	if job.Config != nil {
+		run.Config = *job.Config
+	}
+	if job.StartedAt != nil {
+		run.StartedAt = *job.StartedAt
+	}
+	if job.FinishedAt != nil {
+		run.FinishedAt = *job.FinishedAt
+	}
+	s.saveRun(run)
+}
+
+// @Summary List past runs
+// @Description Returns the kept runs without their results, most recent first
+// @Tags runs
+// @Produce json
+// @Param url query string false "Only runs of this start URL"
+// @Param limit query int false "At most this many runs"
+// @Success 200 {object} []models.Run
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /runs [get]
+func (s *Server) listRuns(c *gin.Context) {
+	filter := store.Filter{URL: c.Query("url")}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit " + strconv.Quote(v)})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	runs, err := s.runs.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// @Summary Get a past run
+// @Description Returns a kept run with its config, summary and results
+// @Tags runs
+// @Produce json
+// @Produce application/xml
+// @Produce application/sarif+json
+// @Produce text/csv
+// @Produce text/markdown
+// @Produce text/html
+// @Param id path string true "Run ID"
+// @Param category query []string false "Only return results in these error categories" collectionFormat(csv) Enums(dns,tls,connection_refused,connection_reset,timeout,redirect_loop,http_4xx,http_5xx,broken_fragment,soft_404,network,unknown)
+// @Param format query string false "Export the results in this format instead of returning the run; the Accept header is used when omitted" Enums(json,junit,sarif,csv,markdown,html)
+// @Success 200 {object} models.Run
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /runs/{id} [get]
+func (s *Server) getRun(c *gin.Context) {
+	categories, err := categoryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	e, err := exporter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	run, ok := s.loadRun(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	run.Results = filterByCategory(run.Results, categories)
+	if e != nil {
+		writeReport(c, e, export.Report{
+			URL:         run.URL,
+			Links:       run.Results,
+			Truncated:   run.Truncated,
+			GeneratedAt: run.FinishedAt,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// @Summary Get the link graph of a past run
+// @Description Returns every checked URL of a kept run as a node and every page linking to it as an edge. Pass target to only get the referrers of one URL.
+// @Tags runs
+// @Produce json
+// @Param id path string true "Run ID"
+// @Param target query string false "Only return edges pointing at this URL"
+// @Success 200 {object} models.LinkGraph
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /runs/{id}/graph [get]
+func (s *Server) runGraph(c *gin.Context) {
+	graph, err := s.runs.Graph(c.Param("id"))
+	if err != nil {
+		runError(c, err)
+		return
+	}
+	if target := c.Query("target"); target != "" {
+		graph = crawler.Subgraph(graph, target)
+	}
+	c.JSON(http.StatusOK, graph)
+}
+
+// @Summary Compare two runs
+// @Description Classifies every link of the head run against the older base run as newly_broken, fixed, still_broken, discovered, disappeared, unchanged or skipped, and flags changed status codes and response time regressions. Unchanged links without a flag are only counted.
+// @Tags runs
+// @Produce json
+// @Produce application/xml
+// @Produce application/sarif+json
+// @Produce text/csv
+// @Produce text/markdown
+// @Produce text/html
+// @Param id path string true "Base run ID"
+// @Param head path string true "Head run ID"
+// @Param slowdown_factor query number false "Flag links whose response time grew by at least this factor (default 2)"
+// @Param min_slowdown query string false "and by at least this much, e.g. 500ms (default)"
+// @Param format query string false "Export the head run with the diff in this format; the Accept header is used when omitted" Enums(json,junit,sarif,csv,markdown,html)
+// @Success 200 {object} models.RunDiff
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /runs/{id}/diff/{head} [get]
+func (s *Server) diffRuns(c *gin.Context) {
+	opts := crawler.DefaultDiffOptions()
+	if v := c.Query("slowdown_factor"); v != "" {
+		factor, err := strconv.ParseFloat(v, 64)
+		if err != nil || factor < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slowdown_factor must be a number of at least 1"})
+			return
+		}
+		opts.SlowdownFactor = factor
+	}
+	if v := c.Query("min_slowdown"); v != "" {
+		d, err := parseDuration("min_slowdown", v, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		opts.MinSlowdown = d
+	}
+	e, err := exporter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	base, ok := s.loadRun(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	head, ok := s.loadRun(c, c.Param("head"))
+	if !ok {
+		return
+	}
+
+	diff := crawler.Diff(base.Results, head.Results, opts)
+	diff.Base, diff.Head = base.ID, head.ID
+	if e != nil {
+		writeReport(c, e, export.Report{
+			URL:         head.URL,
+			Links:       head.Results,
+			Truncated:   head.Truncated,
+			GeneratedAt: head.FinishedAt,
+			Diff:        &diff,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// @Summary Delete a past run
+// @Tags runs
+// @Param id path string true "Run ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /runs/{id} [delete]
+func (s *Server) deleteRun(c *gin.Context) {
+	if err := s.runs.Delete(c.Param("id")); err != nil {
+		runError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Delete old runs
+// @Description Deletes the runs that finished longer than older_than ago, and applies the server's retention policy
+// @Tags runs
+// @Produce json
+// @Param older_than query string true "Age of the runs to delete, e.g. 168h"
+// @Success 200 {object} models.RunDeletion
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /runs [delete]
+func (s *Server) deleteRuns(c *gin.Context) {
+	age, err := parseDuration("older_than", c.Query("older_than"), 0)
+	if err != nil || age == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "older_than must be a positive duration"})
+		return
+	}
+
+	retention := s.retention
+	if retention.MaxAge == 0 || age < retention.MaxAge {
+		retention.MaxAge = age
+	}
+	deleted, err := store.Prune(s.runs, retention, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.RunDeletion{Deleted: deleted})
+}
+
+// loadRun fetches a run or responds with the error
+func (s *Server) loadRun(c *gin.Context, id string) (models.Run, bool) {
+	run, err := s.runs.Get(id)
+	if err != nil {
+		runError(c, err)
+		return run, false
+	}
+	return run, true
+}
+
+// runError responds with 404 for unknown runs and 500 for store failures
+func runError(c *gin.Context, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
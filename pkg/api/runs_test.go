@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/store"
+)
+
+func TestPreviousRunSkipsIncompleteRuns(t *testing.T) {
+	base := time.Date(2024, 3, 19, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		older []models.Run // Oldest first, all before the current run
+		want  string
+	}{
+		{"no earlier run", nil, ""},
+		{"last run done", []models.Run{{ID: "a", State: models.JobDone}}, "a"},
+		{"failed run skipped", []models.Run{{ID: "a", State: models.JobDone}, {ID: "b", State: models.JobFailed}}, "a"},
+		{"cancelled run skipped", []models.Run{{ID: "a", State: models.JobDone}, {ID: "b", State: models.JobCancelled}}, "a"},
+		{"truncated run skipped", []models.Run{{ID: "a", State: models.JobDone}, {ID: "b", State: models.JobDone, Truncated: true}}, "a"},
+		{"only incomplete runs", []models.Run{{ID: "a", State: models.JobFailed}}, ""},
+		{"latest complete run", []models.Run{{ID: "a", State: models.JobDone}, {ID: "b", State: models.JobDone}}, "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{runs: store.NewMemoryRepository()}
+			for i, run := range tt.older {
+				run.URL = "https://example.com"
+				run.FinishedAt = base.Add(time.Duration(i) * time.Hour)
+				if err := s.runs.Save(&run, models.LinkGraph{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// A run of another URL is never a previous run
+			other := models.Run{ID: "other", URL: "https://other.example", State: models.JobDone, FinishedAt: base.Add(time.Minute)}
+			s.runs.Save(&other, models.LinkGraph{})
+
+			current := models.Run{ID: "current", URL: "https://example.com", State: models.JobDone, FinishedAt: base.Add(24 * time.Hour)}
+			s.runs.Save(&current, models.LinkGraph{})
+
+			prev, ok := s.previousRun(current)
+			if got := prev.ID; ok != (tt.want != "") || got != tt.want {
+				t.Errorf("previousRun() = %q, %v; want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/jobs"
+	"github.com/aocamilo/broken-links-tester/pkg/schedule"
+	"github.com/aocamilo/broken-links-tester/pkg/store"
+	"github.com/gin-gonic/gin"
+)
+
+// scheduleLauncher starts scheduled crawls as jobs. Once the registry
+// dropped a finished job, its state is read from the run kept under the
+// same ID.
+type scheduleLauncher struct {
+	*jobs.Registry
+	runs store.RunRepository
+}
+
+func (l scheduleLauncher) Get(id string) (models.Job, error) {
+	job, err := l.Registry.Get(id)
+	if !errors.Is(err, jobs.ErrNotFound) {
+		return job, err
+	}
+	run, runErr := l.runs.Get(id)
+	if errors.Is(runErr, store.ErrNotFound) {
+		return job, err
+	}
+	if runErr != nil {
+		return job, runErr
+	}
+	return models.Job{
+		ID:        run.ID,
+		URL:       run.URL,
+		Depth:     run.Depth,
+		State:     run.State,
+		Truncated: run.Truncated,
+		Error:     run.Error,
+	}, nil
+}
+
+// @Summary Create a recurring crawl
+// @Description Starts a crawl job whenever the cron expression or interval is due. A due run is skipped while the previous job of the schedule is still queued or running.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param request body models.ScheduleRequest true "Crawl request and timing"
+// @Success 201 {object} models.Schedule
+// @Failure 400 {object} map[string]string
+// @Router /schedules [post]
+func (s *Server) createSchedule(c *gin.Context) {
+	var req models.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sched, err := s.schedules.Create(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Created schedule %s for URL: %s", sched.ID, req.Request.URL)
+	c.JSON(http.StatusCreated, sched)
+}
+
+// @Summary List recurring crawls
+// @Description Returns every schedule with its next run and the state of its last job, oldest first
+// @Tags schedules
+// @Produce json
+// @Success 200 {object} []models.Schedule
+// @Router /schedules [get]
+func (s *Server) listSchedules(c *gin.Context) {
+	c.JSON(http.StatusOK, s.schedules.List())
+}
+
+// @Summary Get a recurring crawl
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} models.Schedule
+// @Failure 404 {object} map[string]string
+// @Router /schedules/{id} [get]
+func (s *Server) getSchedule(c *gin.Context) {
+	sched, err := s.schedules.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sched)
+}
+
+// @Summary Replace a recurring crawl
+// @Description Replaces the crawl request and timing of a schedule and plans its next run; the run history is kept
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Param request body models.ScheduleRequest true "Crawl request and timing"
+// @Success 200 {object} models.Schedule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /schedules/{id} [put]
+func (s *Server) updateSchedule(c *gin.Context) {
+	var req models.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sched, err := s.schedules.Update(c.Param("id"), req)
+	switch {
+	case errors.Is(err, schedule.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, sched)
+	}
+}
+
+// @Summary Delete a recurring crawl
+// @Description Deletes a schedule; a job it already started keeps running
+// @Tags schedules
+// @Param id path string true "Schedule ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /schedules/{id} [delete]
+func (s *Server) deleteSchedule(c *gin.Context) {
+	if err := s.schedules.Delete(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -1,17 +1,25 @@
 package api
 
 import (
+	"context"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/aocamilo/broken-links-tester/internal/models"
 	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/aocamilo/broken-links-tester/pkg/export"
+	"github.com/aocamilo/broken-links-tester/pkg/jobs"
+	"github.com/aocamilo/broken-links-tester/pkg/notify"
+	"github.com/aocamilo/broken-links-tester/pkg/schedule"
+	"github.com/aocamilo/broken-links-tester/pkg/store"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
@@ -20,35 +28,71 @@ import (
 
 // Server represents the HTTP server
 type Server struct {
-	router  *gin.Engine
-	crawler *crawler.Crawler
+	router    *gin.Engine
+	crawler   *crawler.Crawler
+	jobs      *jobs.Registry
+	runs      store.RunRepository
+	retention store.Retention
+	schedules *schedule.Scheduler
+	notifiers *notify.Dispatcher
+	limits    Limits
 }
 
+const (
+	// jobWorkers is how many crawl jobs may run at the same time
+	jobWorkers = 4
+	// shutdownTimeout bounds how long open connections may take to drain
+	shutdownTimeout = 10 * time.Second
+)
+
 // NewServer creates a new server instance
 // @title           Broken Links Tester API
 // @version         1.0
 // @description     API for testing broken links on websites
 // @host            localhost:8080
 // @BasePath        /api
-func NewServer() (*Server, error) {
-	c, err := crawler.NewCrawler()
+func NewServer(cfg Config) (*Server, error) {
+	f, err := crawler.NewFetcher(cfg.Fetcher)
 	if err != nil {
 		return nil, err
 	}
+	c := crawler.NewCrawlerWithFetcher(f)
+
+	var runs store.RunRepository = store.NewMemoryRepository()
+	if cfg.RunsPath != "" {
+		if runs, err = store.OpenBoltRepository(cfg.RunsPath); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
 
 	r := gin.Default()
 	r.Use(cors.Default())
 
 	s := &Server{
-		router:  r,
-		crawler: c,
+		router:    r,
+		crawler:   c,
+		jobs:      jobs.NewRegistry(c, jobWorkers),
+		runs:      runs,
+		retention: cfg.Retention,
+		notifiers: notify.New(nil, notify.DefaultRetryPolicy()),
+		limits:    cfg.Limits,
 	}
+	s.jobs.OnFinish(s.saveJob)
+	s.schedules = schedule.New(scheduleLauncher{s.jobs, s.runs}, s.crawlOptions, nil)
 
 	return s, nil
 }
 
-// Close releases resources
+// Close releases resources. Jobs are waited for before the stores they
+// save their runs to are closed.
 func (s *Server) Close() error {
+	s.schedules.Stop()
+	s.jobs.Close()
+	s.notifiers.Close()
+	if err := s.runs.Close(); err != nil {
+		log.Printf("Failed to close run store: %v", err)
+	}
 	return s.crawler.Close()
 }
 
@@ -56,6 +100,18 @@ func (s *Server) Close() error {
 func (s *Server) Run(port string) error {
 	// Setup routes first
 	s.setupRoutes()
+	s.schedules.Start()
+
+	// Requests derive their context from baseCtx so that shutting down
+	// also stops the crawls they are running
+	baseCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := &http.Server{
+		Addr:        "0.0.0.0:" + port,
+		Handler:     s.router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
 
 	// Create a channel to listen for signals
 	sigChan := make(chan os.Signal, 1)
@@ -64,7 +120,7 @@ func (s *Server) Run(port string) error {
 	// Start the server in a goroutine
 	go func() {
 		log.Printf("Starting server on 0.0.0.0:%s", port)
-		if err := s.router.Run("0.0.0.0:" + port); err != nil {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Printf("Server error: %v", err)
 		}
 	}()
@@ -72,9 +128,14 @@ func (s *Server) Run(port string) error {
 	// Wait for a signal
 	<-sigChan
 
-	// Graceful shutdown
+	// Graceful shutdown: stop the crawls of open requests, then drain
+	// connections; jobs and schedules are stopped by Close
 	log.Println("Shutting down server...")
-	return nil
+	cancel()
+
+	ctx, done := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer done()
+	return srv.Shutdown(ctx)
 }
 
 func (s *Server) setupRoutes() {
@@ -117,6 +178,36 @@ func (s *Server) setupRoutes() {
 		// Check links endpoint
 		api.POST("/check-links", s.checkLinks)
 
+		// Asynchronous crawl jobs
+		api.POST("/jobs", s.createJob)
+		api.GET("/jobs/:id", s.getJob)
+		api.GET("/jobs/:id/events", s.streamJob)
+		api.GET("/jobs/:id/graph", s.jobGraph)
+		api.DELETE("/jobs/:id", s.cancelJob)
+
+		// Finished runs kept in the run store
+		api.GET("/runs", s.listRuns)
+		api.GET("/runs/:id", s.getRun)
+		api.GET("/runs/:id/graph", s.runGraph)
+		api.GET("/runs/:id/diff/:head", s.diffRuns)
+		api.DELETE("/runs/:id", s.deleteRun)
+		api.DELETE("/runs", s.deleteRuns)
+
+		// Recurring crawls
+		api.POST("/schedules", s.createSchedule)
+		api.GET("/schedules", s.listSchedules)
+		api.GET("/schedules/:id", s.getSchedule)
+		api.PUT("/schedules/:id", s.updateSchedule)
+		api.DELETE("/schedules/:id", s.deleteSchedule)
+
+		// Webhook and chat notifications of finished runs
+		api.POST("/notifiers", s.createNotifier)
+		api.GET("/notifiers", s.listNotifiers)
+		api.GET("/notifiers/:id", s.getNotifier)
+		api.PUT("/notifiers/:id", s.updateNotifier)
+		api.DELETE("/notifiers/:id", s.deleteNotifier)
+		api.GET("/notifiers/:id/deliveries", s.notifierDeliveries)
+
 		// Swagger docs
 		api.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 	}
@@ -221,12 +312,24 @@ func listDirectoryFiles(dirPath string, depth, maxFiles int) {
 	}
 }
 
-// @Summary Check for broken links on a website
-// @Description Checks for broken links on a website and returns a result
+// @Summary Check links on a website
+// @Description Tests all links on a website for broken links
+// @Tags links
 // @Accept json
 // @Produce json
-// @Param request body models.CheckRequest true "Check links request"
+// @Param request body models.CheckRequest true "URL and depth parameters"
+// @Produce application/xml
+// @Produce application/sarif+json
+// @Produce text/csv
+// @Produce text/markdown
+// @Produce text/html
+// @Param category query []string false "Only return links in these error categories" collectionFormat(csv) Enums(dns,tls,connection_refused,connection_reset,timeout,redirect_loop,http_4xx,http_5xx,broken_fragment,soft_404,network,unknown)
+// @Param format query string false "Report format; the Accept header is used when omitted" Enums(json,junit,sarif,csv,markdown,html)
 // @Success 200 {object} []models.LinkStatus
+// @Header 200 {string} X-Crawl-Truncated "true when max_duration passed and the results are partial"
+// @Header 200 {string} X-Run-ID "ID of the run kept in the run store"
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /check-links [post]
 func (s *Server) checkLinks(c *gin.Context) {
 	var req models.CheckRequest
@@ -244,10 +347,54 @@ func (s *Server) checkLinks(c *gin.Context) {
 		return
 	}
 
-	results := s.crawler.CheckLinks(req.URL, req.Depth)
-	c.JSON(http.StatusOK, results)
+	opts, err := s.crawlOptions(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	categories, err := categoryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	e, err := exporter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	started := time.Now()
+	result := s.crawler.CheckLinksWithOptions(c.Request.Context(), req.URL, req.Depth, opts)
+	if result.Truncated {
+		c.Header("X-Crawl-Truncated", "true")
+	}
+	if run, err := s.saveRun(models.Run{
+		URL:        req.URL,
+		Depth:      req.Depth,
+		Config:     req,
+		State:      models.JobDone,
+		Truncated:  result.Truncated,
+		StartedAt:  started,
+		FinishedAt: time.Now(),
+		Results:    result.Links,
+	}); err == nil {
+		c.Header("X-Run-ID", run.ID)
+	}
+	links := filterByCategory(result.Links, categories)
+	if e != nil {
+		writeReport(c, e, export.Report{
+			URL:         req.URL,
+			Links:       links,
+			Truncated:   result.Truncated,
+			GeneratedAt: time.Now(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, links)
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
-} 
\ No newline at end of file
+}
@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/playwright-community/playwright-go"
+)
+
+// chromiumErrors maps Chromium net error codes, as surfaced by Playwright,
+// to error categories. Prefixes like "ERR_CERT_" match a whole family.
+var chromiumErrors = []struct {
+	code     string
+	category models.ErrorCategory
+}{
+	{"ERR_NAME_NOT_RESOLVED", models.ErrorDNS},
+	{"ERR_NAME_RESOLUTION_FAILED", models.ErrorDNS},
+	{"ERR_CERT_", models.ErrorTLS},
+	{"ERR_SSL_", models.ErrorTLS},
+	{"ERR_BAD_SSL_CLIENT_AUTH_CERT", models.ErrorTLS},
+	{"ERR_CONNECTION_REFUSED", models.ErrorConnectionRefused},
+	{"ERR_CONNECTION_RESET", models.ErrorConnectionReset},
+	{"ERR_CONNECTION_CLOSED", models.ErrorConnectionReset},
+	{"ERR_EMPTY_RESPONSE", models.ErrorConnectionReset},
+	{"ERR_TIMED_OUT", models.ErrorTimeout},
+	{"ERR_CONNECTION_TIMED_OUT", models.ErrorTimeout},
+	{"ERR_TOO_MANY_REDIRECTS", models.ErrorRedirectLoop},
+}
+
+// ClassifyError returns the category of a fetch error from net/http or Playwright
+func ClassifyError(err error) models.ErrorCategory {
+	if err == nil {
+		return ""
+	}
+
+	var redirectErr *RedirectError
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	var netErr net.Error
+	var opErr *net.OpError
+
+	switch {
+	case errors.As(err, &redirectErr):
+		return models.ErrorRedirectLoop
+	case errors.As(err, &dnsErr):
+		return models.ErrorDNS
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidCert), errors.As(err, &recordErr):
+		return models.ErrorTLS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return models.ErrorConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return models.ErrorConnectionReset
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, playwright.ErrTimeout),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return models.ErrorTimeout
+	}
+
+	msg := err.Error()
+	for _, e := range chromiumErrors {
+		if strings.Contains(msg, "net::"+e.code) {
+			return e.category
+		}
+	}
+	switch {
+	case strings.Contains(msg, "tls:") || strings.Contains(msg, "x509:"):
+		return models.ErrorTLS
+	case strings.Contains(msg, "Timeout") && strings.Contains(msg, "exceeded"):
+		// Playwright navigation timeouts
+		return models.ErrorTimeout
+	case errors.As(err, &opErr) || strings.Contains(msg, "net::ERR_"):
+		return models.ErrorNetwork
+	}
+	return models.ErrorUnknown
+}
+
+// ClassifyStatus returns the category of an HTTP status code, or "" for
+// statuses that count as working
+func ClassifyStatus(code int) models.ErrorCategory {
+	switch {
+	case code >= 400 && code < 500:
+		return models.ErrorHTTPClient
+	case code >= 500:
+		return models.ErrorHTTPServer
+	}
+	return ""
+}
+
+// CountCategories counts broken links per error category
+func CountCategories(links []models.LinkStatus) map[models.ErrorCategory]int {
+	counts := make(map[models.ErrorCategory]int)
+	for _, link := range links {
+		if link.ErrorCategory != "" {
+			counts[link.ErrorCategory]++
+		}
+	}
+	return counts
+}
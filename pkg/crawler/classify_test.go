@@ -0,0 +1,142 @@
+package crawler
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestClassifyError(t *testing.T) {
+	// urlErr wraps err the way http.Client.Do does
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://example.com/", Err: err}
+	}
+	opErr := func(op string, err error) error {
+		return &net.OpError{Op: op, Net: "tcp", Err: os.NewSyscallError(op, err)}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want models.ErrorCategory
+	}{
+		{"nil", nil, ""},
+		{"dns", urlErr(&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}), models.ErrorDNS},
+		{"unknown authority", urlErr(x509.UnknownAuthorityError{}), models.ErrorTLS},
+		{"hostname mismatch", urlErr(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "example.com"}), models.ErrorTLS},
+		{"tls message", errors.New("remote error: tls: handshake failure"), models.ErrorTLS},
+		{"connection refused", urlErr(opErr("connect", syscall.ECONNREFUSED)), models.ErrorConnectionRefused},
+		{"connection reset", urlErr(opErr("read", syscall.ECONNRESET)), models.ErrorConnectionReset},
+		{"broken pipe", urlErr(opErr("write", syscall.EPIPE)), models.ErrorConnectionReset},
+		{"deadline", urlErr(context.DeadlineExceeded), models.ErrorTimeout},
+		{"playwright timeout", fmt.Errorf("goto: %w", playwright.ErrTimeout), models.ErrorTimeout},
+		{"playwright timeout message", errors.New("Timeout 30000ms exceeded."), models.ErrorTimeout},
+		{"redirect loop", urlErr(&RedirectError{Loop: true}), models.ErrorRedirectLoop},
+		{"chromium dns", errors.New("page.goto: net::ERR_NAME_NOT_RESOLVED at https://example.invalid/"), models.ErrorDNS},
+		{"chromium certificate", errors.New("net::ERR_CERT_DATE_INVALID at https://example.com/"), models.ErrorTLS},
+		{"chromium refused", errors.New("net::ERR_CONNECTION_REFUSED at http://localhost:1/"), models.ErrorConnectionRefused},
+		{"chromium closed", errors.New("net::ERR_CONNECTION_CLOSED at https://example.com/"), models.ErrorConnectionReset},
+		{"chromium timeout", errors.New("net::ERR_TIMED_OUT at https://example.com/"), models.ErrorTimeout},
+		{"chromium redirects", errors.New("net::ERR_TOO_MANY_REDIRECTS at https://example.com/"), models.ErrorRedirectLoop},
+		{"chromium other", errors.New("net::ERR_ADDRESS_UNREACHABLE at https://example.com/"), models.ErrorNetwork},
+		{"other network error", urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no route to host")}), models.ErrorNetwork},
+		{"unknown", errors.New("unsupported protocol scheme \"ftp\""), models.ErrorUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want models.ErrorCategory
+	}{
+		{200, ""},
+		{204, ""},
+		{301, ""},
+		{399, ""},
+		{400, models.ErrorHTTPClient},
+		{404, models.ErrorHTTPClient},
+		{429, models.ErrorHTTPClient},
+		{500, models.ErrorHTTPServer},
+		{503, models.ErrorHTTPServer},
+	}
+	for _, tt := range tests {
+		if got := ClassifyStatus(tt.code); got != tt.want {
+			t.Errorf("ClassifyStatus(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCountCategories(t *testing.T) {
+	links := []models.LinkStatus{
+		{IsWorking: true},
+		{ErrorCategory: models.ErrorHTTPClient},
+		{ErrorCategory: models.ErrorHTTPClient},
+		{ErrorCategory: models.ErrorDNS},
+	}
+	got := CountCategories(links)
+	want := map[models.ErrorCategory]int{models.ErrorHTTPClient: 2, models.ErrorDNS: 1}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("CountCategories() = %v, want %v", got, want)
+	}
+}
+
+func TestFetchErrorsAreClassified(t *testing.T) {
+	// A port nothing listens on anymore
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedURL := "http://" + ln.Addr().String() + "/"
+	ln.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want models.ErrorCategory
+	}{
+		{"refused", closedURL, models.ErrorConnectionRefused},
+		{"untrusted certificate", tlsServer.URL + "/", models.ErrorTLS},
+		{"timeout", slow.URL + "/", models.ErrorTimeout},
+	}
+	f := NewHTTPFetcher()
+	defer f.Close()
+	opts := DefaultBrowserOptions()
+	opts.Timeout = 100 * time.Millisecond
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.Fetch(context.Background(), tt.url, opts)
+			if got := ClassifyError(err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %q, want %q", err, got, tt.want)
+			}
+		})
+	}
+}
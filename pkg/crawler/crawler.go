@@ -1,376 +1,139 @@
 package crawler
 
 import (
-	"fmt"
-	"io"
-	"log"
-	"net/http"
-	"net/url"
-	"strings"
-	"sync"
+	"context"
 	"time"
 
 	"github.com/aocamilo/broken-links-tester/internal/models"
-	"github.com/playwright-community/playwright-go"
-
-	"golang.org/x/net/html"
 )
 
+// Crawler checks links through a shared Fetcher. It is safe for concurrent
+// use: every CheckLinks call keeps its state in its own session.
 type Crawler struct {
-	pw      *playwright.Playwright
-	browser playwright.Browser
-	visited sync.Map
-	results []models.LinkStatus
-	mu      sync.Mutex
-	wg      sync.WaitGroup
+	fetcher Fetcher
+	// head checks sub-resources and leaf links; it is shared between
+	// crawls so their connections are reused
+	head *HeadFetcher
 }
 
+// Page load states a navigation can wait for
+const (
+	WaitUntilLoad             = "load"
+	WaitUntilDOMContentLoaded = "domcontentloaded"
+	WaitUntilNetworkIdle      = "networkidle"
+)
+
 // BrowserOptions contains options for browser launch
 type BrowserOptions struct {
-	Timeout     time.Duration
-	MaxRetries  int
-	RetryDelay  time.Duration
+	Timeout       time.Duration // Bound for each request; zero means none
 	MaxConcurrent int
+	WaitUntil     string // Load state to wait for; only used by the Playwright fetcher
 }
 
 // DefaultBrowserOptions returns default browser options
 func DefaultBrowserOptions() BrowserOptions {
 	return BrowserOptions{
-		Timeout:     60 * time.Second,  // 60 seconds timeout
-		MaxRetries:  3,                 // 3 retries
-		RetryDelay:  2 * time.Second,   // 2 seconds between retries
-		MaxConcurrent: 5,               // Max 5 concurrent requests
+		Timeout:       60 * time.Second,     // 60 seconds timeout
+		MaxConcurrent: 5,                    // Max 5 concurrent requests
+		WaitUntil:     WaitUntilNetworkIdle, // Wait for the network to settle
 	}
 }
 
-func NewCrawler() (*Crawler, error) {
-	// Install Playwright
-	err := playwright.Install()
-	if err != nil {
-		return nil, fmt.Errorf("failed to install Playwright driver: %v", err)
-	}
-
-	pw, err := playwright.Run()
-	if err != nil {
-		return nil, fmt.Errorf("failed to run Playwright: %v", err)
-	}
-
-	// Try with various browser options
-	browserOpts := []playwright.BrowserTypeLaunchOptions{
-		// Default options
-		{
-			Headless: playwright.Bool(true),
-		},
-		// No sandbox
-		{
-			Headless: playwright.Bool(true),
-			Args:     []string{"--no-sandbox", "--disable-setuid-sandbox"},
-		},
-		// No sandbox + other options
-		{
-			Headless: playwright.Bool(true),
-			Args:     []string{"--no-sandbox", "--disable-setuid-sandbox", "--disable-dev-shm-usage"},
-		},
-	}
-
-	var browser playwright.Browser
-	var launchErr error
-	
-	for _, opts := range browserOpts {
-		browser, launchErr = pw.Chromium.Launch(opts)
-		if launchErr == nil {
-			break
-		}
-		log.Printf("Failed to launch browser with options %+v: %v", opts, launchErr)
-	}
-	
-	if launchErr != nil {
-		pw.Stop()
-		return nil, fmt.Errorf("all browser launch attempts failed: %v", launchErr)
-	}
-
-	return &Crawler{
-		pw:      pw,
-		browser: browser,
-	}, nil
+// CrawlOptions controls a single CheckLinks run
+type CrawlOptions struct {
+	Browser BrowserOptions
+	// Retry decides which failures are retried and how long to back off
+	Retry RetryPolicy
+	// MaxDuration bounds the whole crawl; zero means no deadline
+	MaxDuration time.Duration
+	// Scope limits which pages have their links followed
+	Scope Scope
+	// Filter drops discovered URLs before they are enqueued; nil keeps all
+	Filter *URLFilter
+	// Normalize selects how URL variants are folded together for deduplication
+	Normalize NormalizeOptions
+	// IgnoreRobots skips robots.txt rules and Crawl-delay, e.g. for sites we own
+	IgnoreRobots bool
+	// Politeness limits concurrency and request rate per host
+	Politeness PolitenessOptions
+	// MaxRedirects is the longest redirect chain not flagged as too long
+	MaxRedirects int
+	// SoftErrors detects 2xx pages that are really not-found pages
+	SoftErrors SoftErrorOptions
+	// HeadFirst checks links that won't be expanded, because the depth is
+	// exhausted or they are out of scope, with a HEAD request instead of
+	// loading them as pages. Soft 404s cannot be detected on such links.
+	HeadFirst bool
+	// Resources lists the sub-resource types checked besides anchors,
+	// e.g. ResourceImage; they are never recursed into
+	Resources []string
+	// OnProgress, if set, is called every time the crawl counters change
+	OnProgress func(models.CrawlProgress)
+	// OnResult, if set, is called as soon as a link status is recorded
+	OnResult func(models.LinkStatus)
 }
 
-func (c *Crawler) Close() error {
-	if c.browser != nil {
-		if err := c.browser.Close(); err != nil {
-			return err
-		}
-	}
-	if c.pw != nil {
-		return c.pw.Stop()
+// DefaultCrawlOptions returns default crawl options
+func DefaultCrawlOptions() CrawlOptions {
+	return CrawlOptions{
+		Browser:    DefaultBrowserOptions(),
+		Retry:      DefaultRetryPolicy(),
+		Scope:      Scope{Mode: ScopeAll},
+		Normalize:  DefaultNormalizeOptions(),
+		Politeness: DefaultPolitenessOptions(),
+		// Well-behaved sites need at most a couple of hops (e.g. http -> https -> www)
+		MaxRedirects: 3,
+		SoftErrors:   DefaultSoftErrorOptions(),
+		HeadFirst:    true,
 	}
-	return nil
-}
-
-func (c *Crawler) CheckLinks(baseURL string, maxDepth int) []models.LinkStatus {
-	opts := DefaultBrowserOptions()
-	c.visited = sync.Map{}
-	c.results = []models.LinkStatus{}
-	
-	// Create a semaphore to limit concurrent requests
-	sem := make(chan struct{}, opts.MaxConcurrent)
-	
-	// Start with the base URL at depth -1
-	c.wg.Add(1)
-	go c.crawl(baseURL, "", maxDepth, -1, opts, sem)
-	
-	// Wait for all crawling goroutines to finish
-	c.wg.Wait()
-	return c.results
 }
 
-func (c *Crawler) crawl(currentURL, parentURL string, maxDepth, currentDepth int, opts BrowserOptions, sem chan struct{}) {
-	defer c.wg.Done()
-
-	// Check depth before doing anything else
-	if currentDepth >= maxDepth {
-		return
-	}
-
-	// Check if URL was already visited
-	if _, visited := c.visited.LoadOrStore(currentURL, true); visited {
-		return
-	}
-
-	log.Printf("Crawling URL: %s at depth %d\n", currentURL, currentDepth)
-
-	start := time.Now()
-	
-	// Acquire semaphore
-	sem <- struct{}{}
-	defer func() { <-sem }() // Release semaphore when done
-
-	// Create a new context for this page
-	context, err := c.browser.NewContext()
-	if err != nil {
-		log.Printf("Error creating context for %s: %v\n", currentURL, err)
-		c.recordError(currentURL, parentURL, currentDepth, err, time.Since(start))
-		return
-	}
-	defer context.Close()
-
-	// Create a new page
-	page, err := context.NewPage()
-	if err != nil {
-		log.Printf("Error creating page for %s: %v\n", currentURL, err)
-		c.recordError(currentURL, parentURL, currentDepth, err, time.Since(start))
-		return
-	}
-
-	// Try to navigate with retries
-	var resp playwright.Response
-	var navErr error
-	for i := 0; i < opts.MaxRetries; i++ {
-		resp, navErr = page.Goto(currentURL, playwright.PageGotoOptions{
-			WaitUntil: playwright.WaitUntilStateNetworkidle,
-			Timeout:   playwright.Float(float64(opts.Timeout.Milliseconds())),
-		})
-		if navErr == nil {
-			break
-		}
-		log.Printf("Attempt %d failed for %s: %v\n", i+1, currentURL, navErr)
-		if i < opts.MaxRetries-1 {
-			time.Sleep(opts.RetryDelay)
-		}
-	}
-
-	if navErr != nil {
-		log.Printf("All attempts failed for %s: %v\n", currentURL, navErr)
-		c.recordError(currentURL, parentURL, currentDepth, navErr, time.Since(start))
-		return
-	}
-
-	responseTime := time.Since(start)
-
-	status := models.LinkStatus{
-		URL:          currentURL,
-		ParentURL:    parentURL,
-		Depth:        currentDepth + 1,
-		ResponseTime: responseTime.String(),
-		LastChecked:  time.Now(),
-		StatusCode:   resp.Status(),
-		IsWorking:    resp.Status() >= 200 && resp.Status() < 400,
-	}
-
-	// Extract links using JavaScript
-	links, err := c.extractLinksFromPage(page)
-	if err != nil {
-		log.Printf("Error extracting links from %s: %v\n", currentURL, err)
-	}
-
-	log.Printf("Found %d links in %s\n", len(links), currentURL)
-	
-	// Only crawl links if we haven't reached max depth
-	if currentDepth < maxDepth-1 {
-		// Launch a new goroutine for each discovered link
-		for _, link := range links {
-			log.Printf("Found link: %s in page %s at depth %d\n", link, currentURL, currentDepth+1)
-			c.wg.Add(1)
-			go c.crawl(link, currentURL, maxDepth, currentDepth+1, opts, sem)
-		}
-	}
-
-	c.mu.Lock()
-	c.results = append(c.results, status)
-	c.mu.Unlock()
+// Result is the outcome of a crawl
+type Result struct {
+	Links []models.LinkStatus
+	// Truncated is set when the crawl stopped early because its deadline
+	// passed or its context was cancelled; Links then holds partial results
+	Truncated bool
 }
 
-func (c *Crawler) extractLinksFromPage(page playwright.Page) ([]string, error) {
-	// Execute JavaScript to get all links
-	links, err := page.Evaluate(`() => {
-		const links = new Set();
-		document.querySelectorAll('a[href]').forEach(el => {
-			const href = el.href;
-			if (href && !href.startsWith('javascript:') && !href.startsWith('#') && 
-				!href.startsWith('mailto:') && !href.startsWith('tel:')) {
-				links.add(href);
-			}
-		});
-		return Array.from(links);
-	}`)
+// NewCrawler creates a crawler backed by a headless Playwright browser
+func NewCrawler() (*Crawler, error) {
+	f, err := NewPlaywrightFetcher()
 	if err != nil {
 		return nil, err
 	}
-
-	// Convert the interface{} to []string
-	var result []string
-	if linksArr, ok := links.([]interface{}); ok {
-		for _, link := range linksArr {
-			if strLink, ok := link.(string); ok {
-				result = append(result, strLink)
-			}
-		}
-	}
-
-	return result, nil
+	return NewCrawlerWithFetcher(f), nil
 }
 
-func (c *Crawler) recordError(currentURL, parentURL string, depth int, err error, responseTime time.Duration) {
-	status := models.LinkStatus{
-		URL:          currentURL,
-		ParentURL:    parentURL,
-		Depth:        depth,
-		ResponseTime: responseTime.String(),
-		LastChecked:  time.Now(),
-		Error:        err.Error(),
-		IsWorking:    false,
-	}
-
-	c.mu.Lock()
-	c.results = append(c.results, status)
-	c.mu.Unlock()
-}
-
-func (c *Crawler) createRequest(url string) (*http.Request, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// NewCrawlerWithFetcher creates a crawler that loads pages through the given fetcher
+func NewCrawlerWithFetcher(f Fetcher) *Crawler {
+	return &Crawler{
+		fetcher: f,
+		head:    NewHeadFetcher(),
 	}
-	
-	// Add headers to mimic a browser
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-	
-	return req, nil
 }
 
-func (c *Crawler) extractLinksFromNode(body io.Reader, baseURL string) []string {
-	links := make([]string, 0)
-	doc, err := html.Parse(body)
-	if err != nil {
-		log.Printf("Error parsing HTML: %v\n", err)
-		return links
-	}
-
-	var traverse func(*html.Node)
-	traverse = func(n *html.Node) {
-		if n.Type == html.ElementNode && (n.Data == "a" || n.Data == "link") {
-			for _, attr := range n.Attr {
-				if attr.Key == "href" {
-					link := attr.Val
-					if strings.HasPrefix(link, "#") || link == "" || strings.HasPrefix(link, "mailto:") || strings.HasPrefix(link, "tel:") {
-						continue
-					}
-
-					base, err := url.Parse(baseURL)
-					if err != nil {
-						continue
-					}
-
-					absoluteURL, err := resolveURL(base, link)
-					if err != nil {
-						continue
-					}
-
-					if strings.HasPrefix(absoluteURL, "http") {
-						links = append(links, absoluteURL)
-					}
-				}
-			}
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			traverse(c)
-		}
+func (c *Crawler) Close() error {
+	c.head.Close()
+	if c.fetcher != nil {
+		return c.fetcher.Close()
 	}
-	traverse(doc)
-	return links
+	return nil
 }
 
-func (c *Crawler) extractLinks(body io.Reader, baseURL string) []string {
-	links := make([]string, 0)
-	z := html.NewTokenizer(body)
-	base, _ := url.Parse(baseURL)
-
-	for {
-		tt := z.Next()
-		switch tt {
-		case html.ErrorToken:
-			return links
-		case html.StartTagToken, html.SelfClosingTagToken:
-			token := z.Token()
-			if token.Data == "a" || token.Data == "link" {
-				for _, attr := range token.Attr {
-					if attr.Key == "href" {
-						link := attr.Val
-						if strings.HasPrefix(link, "#") || link == "" || strings.HasPrefix(link, "mailto:") || strings.HasPrefix(link, "tel:") {
-							continue
-						}
-						
-						absoluteURL, err := resolveURL(base, link)
-						if err != nil {
-							continue
-						}
-						
-						if strings.HasPrefix(absoluteURL, "http") {
-							links = append(links, absoluteURL)
-						}
-					}
-				}
-			}
-		}
-	}
+// CheckLinks crawls baseURL up to maxDepth with the default options
+func (c *Crawler) CheckLinks(ctx context.Context, baseURL string, maxDepth int) Result {
+	return c.CheckLinksWithOptions(ctx, baseURL, maxDepth, DefaultCrawlOptions())
 }
 
-func resolveURL(base *url.URL, ref string) (string, error) {
-	refURL, err := url.Parse(ref)
-	if err != nil {
-		return "", err
+// CheckLinksWithOptions crawls baseURL up to maxDepth using the given options.
+// Cancelling ctx or reaching opts.MaxDuration stops the crawl and returns
+// the results gathered so far.
+func (c *Crawler) CheckLinksWithOptions(ctx context.Context, baseURL string, maxDepth int, opts CrawlOptions) Result {
+	if opts.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxDuration)
+		defer cancel()
 	}
-	resolvedURL := base.ResolveReference(refURL)
-	return resolvedURL.String(), nil
+	return newSession(ctx, c.fetcher, c.head, maxDepth, opts).run(baseURL)
 }
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-} 
\ No newline at end of file
@@ -0,0 +1,209 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// serveSite serves the given HTML pages by path; any other path is a 404
+func serveSite(t *testing.T, pages map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testOptions are crawl options without rate limits and retries,
+// so tests against local servers run fast
+func testOptions() CrawlOptions {
+	opts := DefaultCrawlOptions()
+	opts.Politeness = PolitenessOptions{}
+	opts.Retry.MaxAttempts = 1
+	return opts
+}
+
+// linkPage returns an HTML page linking to the given paths
+func linkPage(paths ...string) string {
+	var b strings.Builder
+	b.WriteString("<html><body>")
+	for _, p := range paths {
+		fmt.Fprintf(&b, `<a href="%s">%s</a>`, p, p)
+	}
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+func TestCrawlStopsEarly(t *testing.T) {
+	// /slow only answers once the client gives up
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, linkPage("/fast", "/slow"))
+		case "/fast":
+			fmt.Fprint(w, "<html></html>")
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		maxDuration time.Duration
+		cancelAfter time.Duration
+	}{
+		{"deadline", 300 * time.Millisecond, 0},
+		{"cancelled", 0, 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.cancelAfter > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				time.AfterFunc(tt.cancelAfter, cancel)
+			}
+			opts := testOptions()
+			opts.MaxDuration = tt.maxDuration
+
+			c := NewCrawlerWithFetcher(NewHTTPFetcher())
+			defer c.Close()
+			start := time.Now()
+			result := c.CheckLinksWithOptions(ctx, srv.URL+"/", 2, opts)
+
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("crawl took %v after being stopped", elapsed)
+			}
+			if !result.Truncated {
+				t.Error("Truncated = false, want true")
+			}
+			checked := map[string]bool{}
+			for _, l := range result.Links {
+				checked[strings.TrimPrefix(l.URL, srv.URL)] = true
+			}
+			if !checked["/"] || !checked["/fast"] {
+				t.Errorf("partial results %v, want / and /fast", checked)
+			}
+			// The abandoned request is not reported as a broken link
+			if checked["/slow"] {
+				t.Error("abandoned /slow was recorded")
+			}
+		})
+	}
+}
+
+func TestCompleteCrawlIsNotTruncated(t *testing.T) {
+	srv := serveSite(t, map[string]string{
+		"/":  linkPage("/a"),
+		"/a": linkPage("/"),
+	})
+	opts := testOptions()
+	opts.MaxDuration = time.Minute
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 2, opts)
+	if result.Truncated || len(result.Links) != 2 {
+		t.Errorf("got %d links, truncated %v; want 2 links, not truncated", len(result.Links), result.Truncated)
+	}
+}
+
+func TestConcurrentCheckLinksAreIsolated(t *testing.T) {
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+
+	// Every site has a different number of pages and broken links, so
+	// results or counters leaking between sessions change the totals
+	const sites = 6
+	type outcome struct {
+		base     string
+		result   Result
+		progress models.CrawlProgress
+		pages    int
+		broken   int
+	}
+	outcomes := make([]*outcome, sites)
+	for i := range outcomes {
+		pages := map[string]string{}
+		var links []string
+		for p := 0; p <= i; p++ {
+			path := fmt.Sprintf("/page-%d", p)
+			pages[path] = linkPage("/")
+			links = append(links, path)
+		}
+		for b := 0; b < i%3; b++ {
+			links = append(links, fmt.Sprintf("/missing-%d", b))
+		}
+		pages["/"] = linkPage(links...)
+		srv := serveSite(t, pages)
+		outcomes[i] = &outcome{base: srv.URL + "/", pages: len(links) + 1, broken: i % 3}
+	}
+
+	var wg sync.WaitGroup
+	for _, o := range outcomes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var mu sync.Mutex
+			opts := testOptions()
+			opts.OnProgress = func(p models.CrawlProgress) {
+				mu.Lock()
+				o.progress = p
+				mu.Unlock()
+			}
+			o.result = c.CheckLinksWithOptions(context.Background(), o.base, 2, opts)
+		}()
+	}
+	wg.Wait()
+
+	for _, o := range outcomes {
+		if got := len(o.result.Links); got != o.pages {
+			t.Errorf("%s: got %d results, want %d", o.base, got, o.pages)
+		}
+		seen := map[string]bool{}
+		broken := 0
+		for _, l := range o.result.Links {
+			if !strings.HasPrefix(l.URL, o.base) {
+				t.Errorf("%s: result %s belongs to another session", o.base, l.URL)
+			}
+			if seen[l.URL] {
+				t.Errorf("%s: %s was checked twice", o.base, l.URL)
+			}
+			seen[l.URL] = true
+			if !l.IsWorking {
+				broken++
+			}
+		}
+		if broken != o.broken {
+			t.Errorf("%s: got %d broken links, want %d", o.base, broken, o.broken)
+		}
+		want := models.CrawlProgress{Visited: o.pages, Broken: o.broken}
+		if o.progress != want {
+			t.Errorf("%s: final progress %+v, want %+v", o.base, o.progress, want)
+		}
+		if o.result.Truncated {
+			t.Errorf("%s: crawl was truncated", o.base)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// DiffOptions controls how two runs are compared
+type DiffOptions struct {
+	// Normalize folds URL variants so both runs key links the same way
+	Normalize NormalizeOptions
+	// SlowdownFactor and MinSlowdown flag a link as slower when its response
+	// time grew by at least this factor and at least this much
+	SlowdownFactor float64
+	MinSlowdown    time.Duration
+}
+
+// DefaultDiffOptions flags links that got twice as slow by half a second or more
+func DefaultDiffOptions() DiffOptions {
+	return DiffOptions{
+		Normalize:      DefaultNormalizeOptions(),
+		SlowdownFactor: 2,
+		MinSlowdown:    500 * time.Millisecond,
+	}
+}
+
+// diffKey identifies a link across runs
+type diffKey struct {
+	url          string
+	resourceType string
+}
+
+// Diff compares the links of an older and a newer run. Links are matched
+// by normalized URL and resource type; broken fragment results keep their
+// fragment so they don't collide with the page they point into.
+// A broken link only found by the newer run counts as newly broken.
+// Skipped links say nothing about whether a link works: one the newer run
+// skipped is reported as skipped, never as fixed, and one the older run
+// skipped counts as not found by it.
+func Diff(before, after []models.LinkStatus, opts DiffOptions) models.RunDiff {
+	diff := models.RunDiff{
+		Counts:  make(map[models.LinkChangeKind]int),
+		Changes: []models.LinkChange{},
+	}
+
+	old := make(map[diffKey]*models.LinkStatus, len(before))
+	for i := range before {
+		// Keep the first of duplicate links, as the newer run does
+		if key := opts.key(before[i]); old[key] == nil {
+			old[key] = &before[i]
+		}
+	}
+
+	seen := make(map[diffKey]bool, len(after))
+	for i := range after {
+		link := &after[i]
+		key := opts.key(*link)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		change := models.LinkChange{
+			URL:          link.URL,
+			ResourceType: link.ResourceType,
+			Before:       old[key],
+			After:        link,
+		}
+		switch prev := old[key]; {
+		case link.Skipped:
+			change.Change = models.ChangeSkipped
+		case isBroken(*link) && prev != nil && isBroken(*prev):
+			change.Change = models.ChangeStillBroken
+		case isBroken(*link):
+			change.Change = models.ChangeNewlyBroken
+		case prev == nil || prev.Skipped:
+			change.Change = models.ChangeDiscovered
+		case isBroken(*prev):
+			change.Change = models.ChangeFixed
+		default:
+			change.Change = models.ChangeUnchanged
+		}
+
+		if prev := change.Before; prev != nil {
+			change.StatusChanged = prev.StatusCode != 0 && link.StatusCode != 0 && prev.StatusCode != link.StatusCode
+			change.Slower = opts.slower(*prev, *link)
+		}
+		addChange(&diff, change)
+	}
+
+	for i := range before {
+		link := &before[i]
+		key := opts.key(*link)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		addChange(&diff, models.LinkChange{
+			URL:          link.URL,
+			ResourceType: link.ResourceType,
+			Change:       models.ChangeDisappeared,
+			Before:       link,
+		})
+	}
+
+	return diff
+}
+
+func (o DiffOptions) key(link models.LinkStatus) diffKey {
+	u := link.URL
+	if !link.BrokenFragment {
+		u = o.Normalize.Normalize(u)
+	}
+	resourceType := link.ResourceType
+	if resourceType == "" {
+		resourceType = ResourcePage
+	}
+	return diffKey{url: u, resourceType: resourceType}
+}
+
+// slower reports whether the response time regressed beyond the thresholds
+func (o DiffOptions) slower(before, after models.LinkStatus) bool {
+	prev, err1 := time.ParseDuration(before.ResponseTime)
+	cur, err2 := time.ParseDuration(after.ResponseTime)
+	if err1 != nil || err2 != nil || prev <= 0 {
+		return false
+	}
+	return cur-prev >= o.MinSlowdown && float64(cur) >= float64(prev)*o.SlowdownFactor
+}
+
+// addChange counts a change and lists it unless there is nothing to report
+func addChange(diff *models.RunDiff, change models.LinkChange) {
+	diff.Counts[change.Change]++
+	if change.StatusChanged {
+		diff.StatusChanged++
+	}
+	if change.Slower {
+		diff.Slower++
+	}
+	if change.Change != models.ChangeUnchanged || change.StatusChanged || change.Slower {
+		diff.Changes = append(diff.Changes, change)
+	}
+}
+
+func isBroken(link models.LinkStatus) bool {
+	return !link.IsWorking && !link.Skipped
+}
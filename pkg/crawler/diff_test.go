@@ -0,0 +1,212 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+func working(url string) models.LinkStatus {
+	return models.LinkStatus{URL: url, StatusCode: 200, IsWorking: true, ResponseTime: "100ms"}
+}
+
+func broken(url string, code int) models.LinkStatus {
+	return models.LinkStatus{URL: url, StatusCode: code, ResponseTime: "100ms"}
+}
+
+func TestDiff(t *testing.T) {
+	skipped := models.LinkStatus{URL: "https://example.com/skipped", Skipped: true, SkipReason: "robots"}
+	slow := working("https://example.com/a")
+	slow.ResponseTime = "900ms"
+	slightlySlower := working("https://example.com/a")
+	slightlySlower.ResponseTime = "300ms"
+	fragment := broken("https://example.com/a#missing", 200)
+	fragment.BrokenFragment = true
+	image := broken("https://example.com/a", 404)
+	image.ResourceType = ResourceImage
+
+	tests := []struct {
+		name          string
+		before, after []models.LinkStatus
+		want          map[string]models.LinkChangeKind // Listed changes by URL
+		counts        map[models.LinkChangeKind]int
+		statusChanged int
+		slower        int
+	}{
+		{
+			name:   "newly broken",
+			before: []models.LinkStatus{working("https://example.com/a")},
+			after:  []models.LinkStatus{broken("https://example.com/a", 404)},
+			want:   map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeNewlyBroken},
+			counts: map[models.LinkChangeKind]int{models.ChangeNewlyBroken: 1},
+			// 200 to 404
+			statusChanged: 1,
+		},
+		{
+			name:   "broken link only in the newer run",
+			after:  []models.LinkStatus{broken("https://example.com/a", 500)},
+			want:   map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeNewlyBroken},
+			counts: map[models.LinkChangeKind]int{models.ChangeNewlyBroken: 1},
+		},
+		{
+			name:          "fixed",
+			before:        []models.LinkStatus{broken("https://example.com/a", 404)},
+			after:         []models.LinkStatus{working("https://example.com/a")},
+			want:          map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeFixed},
+			counts:        map[models.LinkChangeKind]int{models.ChangeFixed: 1},
+			statusChanged: 1,
+		},
+		{
+			name:   "still broken",
+			before: []models.LinkStatus{broken("https://example.com/a", 404)},
+			after:  []models.LinkStatus{broken("https://example.com/a", 404)},
+			want:   map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeStillBroken},
+			counts: map[models.LinkChangeKind]int{models.ChangeStillBroken: 1},
+		},
+		{
+			name:          "still broken with another status",
+			before:        []models.LinkStatus{broken("https://example.com/a", 404)},
+			after:         []models.LinkStatus{broken("https://example.com/a", 503)},
+			want:          map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeStillBroken},
+			counts:        map[models.LinkChangeKind]int{models.ChangeStillBroken: 1},
+			statusChanged: 1,
+		},
+		{
+			name:   "discovered and disappeared",
+			before: []models.LinkStatus{working("https://example.com/old")},
+			after:  []models.LinkStatus{working("https://example.com/new")},
+			want: map[string]models.LinkChangeKind{
+				"https://example.com/new": models.ChangeDiscovered,
+				"https://example.com/old": models.ChangeDisappeared,
+			},
+			counts: map[models.LinkChangeKind]int{models.ChangeDiscovered: 1, models.ChangeDisappeared: 1},
+		},
+		{
+			name:   "unchanged links are counted but not listed",
+			before: []models.LinkStatus{working("https://example.com/a")},
+			after:  []models.LinkStatus{working("https://example.com/a")},
+			want:   map[string]models.LinkChangeKind{},
+			counts: map[models.LinkChangeKind]int{models.ChangeUnchanged: 1},
+		},
+		{
+			name:   "skipped in both runs",
+			before: []models.LinkStatus{skipped},
+			after:  []models.LinkStatus{skipped},
+			want:   map[string]models.LinkChangeKind{skipped.URL: models.ChangeSkipped},
+			counts: map[models.LinkChangeKind]int{models.ChangeSkipped: 1},
+		},
+		{
+			name:   "broken link now skipped is not fixed",
+			before: []models.LinkStatus{broken(skipped.URL, 404)},
+			after:  []models.LinkStatus{skipped},
+			want:   map[string]models.LinkChangeKind{skipped.URL: models.ChangeSkipped},
+			counts: map[models.LinkChangeKind]int{models.ChangeSkipped: 1},
+		},
+		{
+			name:   "working link now skipped",
+			before: []models.LinkStatus{working(skipped.URL)},
+			after:  []models.LinkStatus{skipped},
+			want:   map[string]models.LinkChangeKind{skipped.URL: models.ChangeSkipped},
+			counts: map[models.LinkChangeKind]int{models.ChangeSkipped: 1},
+		},
+		{
+			name:   "skipped link now working",
+			before: []models.LinkStatus{skipped},
+			after:  []models.LinkStatus{working(skipped.URL)},
+			want:   map[string]models.LinkChangeKind{skipped.URL: models.ChangeDiscovered},
+			counts: map[models.LinkChangeKind]int{models.ChangeDiscovered: 1},
+		},
+		{
+			name:   "skipped link now broken",
+			before: []models.LinkStatus{skipped},
+			after:  []models.LinkStatus{broken(skipped.URL, 404)},
+			want:   map[string]models.LinkChangeKind{skipped.URL: models.ChangeNewlyBroken},
+			counts: map[models.LinkChangeKind]int{models.ChangeNewlyBroken: 1},
+		},
+
+		{
+			name:   "links match by normalized URL",
+			before: []models.LinkStatus{broken("https://Example.com:443/a?utm_source=x", 404)},
+			after:  []models.LinkStatus{working("https://example.com/a")},
+			want:   map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeFixed},
+			counts: map[models.LinkChangeKind]int{models.ChangeFixed: 1},
+			// 404 to 200
+			statusChanged: 1,
+		},
+		{
+			name:   "broken fragments keep their fragment",
+			before: []models.LinkStatus{working("https://example.com/a")},
+			after:  []models.LinkStatus{working("https://example.com/a"), fragment},
+			want:   map[string]models.LinkChangeKind{"https://example.com/a#missing": models.ChangeNewlyBroken},
+			counts: map[models.LinkChangeKind]int{models.ChangeUnchanged: 1, models.ChangeNewlyBroken: 1},
+		},
+		{
+			name:   "resource types are compared separately",
+			before: []models.LinkStatus{working("https://example.com/a")},
+			after:  []models.LinkStatus{working("https://example.com/a"), image},
+			want:   map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeNewlyBroken},
+			counts: map[models.LinkChangeKind]int{models.ChangeUnchanged: 1, models.ChangeNewlyBroken: 1},
+		},
+		{
+			name:   "slower links are listed",
+			before: []models.LinkStatus{working("https://example.com/a")},
+			after:  []models.LinkStatus{slow},
+			want:   map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeUnchanged},
+			counts: map[models.LinkChangeKind]int{models.ChangeUnchanged: 1},
+			slower: 1,
+		},
+		{
+			name:   "small slowdowns are ignored",
+			before: []models.LinkStatus{working("https://example.com/a")},
+			after:  []models.LinkStatus{slightlySlower},
+			want:   map[string]models.LinkChangeKind{},
+			counts: map[models.LinkChangeKind]int{models.ChangeUnchanged: 1},
+		},
+		{
+			name:   "duplicates count once",
+			before: []models.LinkStatus{broken("https://example.com/a", 404), broken("https://example.com/a#top", 404)},
+			after:  []models.LinkStatus{broken("https://example.com/a", 404), broken("https://example.com/a?utm_medium=email", 404)},
+			want:   map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeStillBroken},
+			counts: map[models.LinkChangeKind]int{models.ChangeStillBroken: 1},
+		},
+		{
+			// Both runs compare their first occurrence of a link
+			name:   "first duplicate wins",
+			before: []models.LinkStatus{broken("https://example.com/a", 404), working("https://example.com/a#top")},
+			after:  []models.LinkStatus{working("https://example.com/a"), broken("https://example.com/a?utm_medium=email", 404)},
+			want:   map[string]models.LinkChangeKind{"https://example.com/a": models.ChangeFixed},
+			counts: map[models.LinkChangeKind]int{models.ChangeFixed: 1},
+			// 404 to 200
+			statusChanged: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Diff(tt.before, tt.after, DefaultDiffOptions())
+
+			got := make(map[string]models.LinkChangeKind, len(diff.Changes))
+			for _, change := range diff.Changes {
+				got[change.URL] = change.Change
+			}
+			if len(got) != len(tt.want) || len(diff.Changes) != len(tt.want) {
+				t.Errorf("Changes = %v, want %v", got, tt.want)
+			}
+			for url, kind := range tt.want {
+				if got[url] != kind {
+					t.Errorf("%s changed %q, want %q", url, got[url], kind)
+				}
+			}
+			for _, kind := range models.LinkChangeKinds {
+				if diff.Counts[kind] != tt.counts[kind] {
+					t.Errorf("Counts[%s] = %d, want %d", kind, diff.Counts[kind], tt.counts[kind])
+				}
+			}
+			if diff.StatusChanged != tt.statusChanged {
+				t.Errorf("StatusChanged = %d, want %d", diff.StatusChanged, tt.statusChanged)
+			}
+			if diff.Slower != tt.slower {
+				t.Errorf("Slower = %d, want %d", diff.Slower, tt.slower)
+			}
+		})
+	}
+}
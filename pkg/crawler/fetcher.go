@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// Supported fetcher backends
+const (
+	BackendPlaywright = "playwright"
+	BackendHTTP       = "http"
+)
+
+// Page is the outcome of fetching a single URL
+type Page struct {
+	URL        string
+	StatusCode int
+	Links      []Link
+	// Anchors are the element ids and <a name> values fragments can point to;
+	// nil when the response was not HTML
+	Anchors []string
+	// Resources are the images, scripts, stylesheets, iframes and media the page references
+	Resources []Resource
+	// Canonical is the absolute <link rel=canonical> URL declared by the page, if any
+	Canonical string
+	// Content is the title and text of HTML pages; nil for anything else
+	Content *PageContent
+	// Redirects are the hops followed before the final response, in order
+	Redirects []models.RedirectHop
+	// RetryAfter is the delay requested by a Retry-After response header
+	RetryAfter time.Duration
+}
+
+// withTimeout bounds ctx by timeout. Zero means no timeout, as it does
+// for Playwright.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// maxContentText caps how much page text is kept for soft-404 detection
+const maxContentText = 64 << 10
+
+// PageContent summarizes an HTML page for soft-404 detection
+type PageContent struct {
+	Title string
+	Text  string // Visible text with whitespace collapsed, capped at maxContentText
+	Size  int    // Size of the HTML in bytes
+}
+
+// Link is one occurrence of an <a href> on a page
+type Link struct {
+	URL    string
+	Source models.LinkSource
+}
+
+// Fetcher loads a URL and returns its status together with the links found on it.
+// Fetch must give up as soon as ctx is done.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string, opts BrowserOptions) (*Page, error)
+	Close() error
+}
+
+// NewFetcher creates the fetcher for the given backend name
+func NewFetcher(backend string) (Fetcher, error) {
+	switch backend {
+	case "", BackendPlaywright:
+		f, err := NewPlaywrightFetcher()
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case BackendHTTP:
+		return NewHTTPFetcher(), nil
+	default:
+		return nil, fmt.Errorf("unknown fetcher backend %q", backend)
+	}
+}
@@ -0,0 +1,42 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestZeroTimeoutMeansNoTimeout(t *testing.T) {
+	srv := serveSite(t, map[string]string{
+		"/":           linkPage("/a"),
+		"/robots.txt": "User-agent: *\nDisallow: /private",
+	})
+	opts := DefaultBrowserOptions()
+	opts.Timeout = 0
+
+	fetchers := []struct {
+		name    string
+		fetcher Fetcher
+	}{
+		{"http", NewHTTPFetcher()},
+		{"head", NewHeadFetcher()},
+	}
+	for _, tt := range fetchers {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.fetcher.Close()
+			page, err := tt.fetcher.Fetch(context.Background(), srv.URL+"/", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200", page.StatusCode)
+			}
+		})
+	}
+
+	t.Run("robots", func(t *testing.T) {
+		if newRobotsCache(0).Allowed(context.Background(), srv.URL+"/private") {
+			t.Error("robots.txt rules were not loaded without a timeout")
+		}
+	})
+}
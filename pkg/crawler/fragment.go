@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// fragmentRef is a link to an anchor inside a page
+type fragmentRef struct {
+	link      string // The link as found, including the fragment
+	target    string // Normalized URL of the page holding the anchor
+	fragment  string
+	parentURL string
+	depth     int
+}
+
+// addFragmentRef remembers a link with a fragment so its anchor can be
+// checked once the target page has been crawled
+func (s *session) addFragmentRef(link, parentURL string, depth int) {
+	u, err := url.Parse(link)
+	if err != nil || u.Fragment == "" {
+		return
+	}
+	fragment := u.Fragment
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ref := fragmentRef{
+		link:      link,
+		target:    s.opts.Normalize.Normalize(u.String()),
+		fragment:  fragment,
+		parentURL: parentURL,
+		depth:     depth,
+	}
+	if !s.fragmentSeen[ref] {
+		s.fragmentSeen[ref] = true
+		s.fragments = append(s.fragments, ref)
+	}
+}
+
+// hasFragment reports whether a link points to an anchor, which can
+// only be checked when the page is loaded. Pages first reached through
+// another link may still be checked with HEAD; loadFragmentTargets
+// loads those.
+func hasFragment(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && u.Fragment != ""
+}
+
+// pageAnchors are the element ids and anchor names of a crawled page
+type pageAnchors struct {
+	statusCode int
+	names      map[string]bool
+}
+
+// setAnchors stores the anchors of a crawled page; nil means the page
+// was not HTML and its fragments cannot be checked
+func (s *session) setAnchors(page *Page, pageURL string) {
+	if page.Anchors == nil {
+		return
+	}
+	names := make(map[string]bool, len(page.Anchors))
+	for _, a := range page.Anchors {
+		names[a] = true
+	}
+	s.mu.Lock()
+	s.anchors[pageURL] = pageAnchors{statusCode: page.StatusCode, names: names}
+	s.mu.Unlock()
+}
+
+// setHeadOnly remembers a working page that was checked without loading it
+func (s *session) setHeadOnly(pageURL string) {
+	s.mu.Lock()
+	s.headOnly[pageURL] = true
+	s.mu.Unlock()
+}
+
+// loadFragmentTargets loads the pages fragment links point to that were
+// only checked with HEAD, so their anchors are known
+func (s *session) loadFragmentTargets() {
+	targets := make(map[string]bool)
+	for _, ref := range s.fragments {
+		if s.headOnly[ref.target] {
+			targets[ref.target] = true
+		}
+	}
+
+	var wg sync.WaitGroup
+	for target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			page, err := s.load(s.ctx, target)
+			if err != nil {
+				log.Printf("Error loading %s to check its anchors: %v\n", target, err)
+				return
+			}
+			if page.StatusCode >= 200 && page.StatusCode < 400 {
+				s.setAnchors(page, target)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// checkFragments reports every fragment link whose target page was
+// crawled but has no element with a matching id or name
+func (s *session) checkFragments() {
+	for _, ref := range s.fragments {
+		anchors, ok := s.anchors[ref.target]
+		// "#top" scrolls to the top of any page
+		if !ok || anchors.names[ref.fragment] || ref.fragment == "top" {
+			continue
+		}
+
+		log.Printf("Broken fragment #%s in %s linked from %s\n", ref.fragment, ref.target, ref.parentURL)
+		s.record(models.LinkStatus{
+			URL:            ref.link,
+			ResourceType:   ResourcePage,
+			ParentURL:      ref.parentURL,
+			Depth:          ref.depth + 1,
+			StatusCode:     anchors.statusCode,
+			LastChecked:    time.Now(),
+			Error:          fmt.Sprintf("fragment #%s not found on %s", ref.fragment, ref.target),
+			BrokenFragment: true,
+			ErrorCategory:  models.ErrorBrokenFragment,
+		})
+	}
+}
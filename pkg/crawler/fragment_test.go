@@ -0,0 +1,32 @@
+package crawler
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFragmentTargetsCheckedWithHeadAreLoaded(t *testing.T) {
+	srv := serveSite(t, map[string]string{
+		// The plain link comes last; the newest goroutine usually runs first,
+		// so its HEAD check tends to win the dedup
+		"/":      linkPage("/guide#install", "/guide#missing", "/guide#top", "/guide"),
+		"/guide": `<html><body><h2 id="install">Install</h2></body></html>`,
+	})
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	for i := 0; i < 5; i++ {
+		result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 1, testOptions())
+
+		var broken []string
+		for _, l := range result.Links {
+			if l.BrokenFragment {
+				broken = append(broken, strings.TrimPrefix(l.URL, srv.URL))
+			}
+		}
+		if len(broken) != 1 || broken[0] != "/guide#missing" {
+			t.Fatalf("broken fragments = %v, want [/guide#missing]", broken)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// BuildGraph turns crawl results into a link graph with one node per
+// checked URL and one edge per referring page, counting occurrences
+func BuildGraph(links []models.LinkStatus) models.LinkGraph {
+	graph := models.LinkGraph{
+		Nodes: make([]models.LinkNode, 0, len(links)),
+		Edges: []models.LinkEdge{},
+	}
+	for _, link := range links {
+		graph.Nodes = append(graph.Nodes, models.LinkNode{
+			URL:          link.URL,
+			ResourceType: link.ResourceType,
+			StatusCode:   link.StatusCode,
+			IsWorking:    link.IsWorking,
+			Skipped:      link.Skipped,
+		})
+
+		// Sources are per occurrence, so repeated links on one page add up
+		index := make(map[string]int)
+		for _, source := range link.Sources {
+			i, ok := index[source.ParentURL]
+			if !ok {
+				i = len(graph.Edges)
+				index[source.ParentURL] = i
+				graph.Edges = append(graph.Edges, models.LinkEdge{
+					Source:       source.ParentURL,
+					Target:       link.URL,
+					ResourceType: link.ResourceType,
+				})
+			}
+			graph.Edges[i].Count++
+		}
+	}
+	return graph
+}
+
+// Subgraph keeps the edges pointing at target together with its node and
+// the nodes of its referrers
+func Subgraph(graph models.LinkGraph, target string) models.LinkGraph {
+	keep := map[string]bool{target: true}
+	sub := models.LinkGraph{Nodes: []models.LinkNode{}, Edges: []models.LinkEdge{}}
+	for _, edge := range graph.Edges {
+		if edge.Target == target {
+			sub.Edges = append(sub.Edges, edge)
+			keep[edge.Source] = true
+		}
+	}
+	for _, node := range graph.Nodes {
+		if keep[node.URL] {
+			sub.Nodes = append(sub.Nodes, node)
+		}
+	}
+	return sub
+}
@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+func TestReferrersAreAggregated(t *testing.T) {
+	srv := serveSite(t, map[string]string{
+		"/":  linkPage("/a", "/shared", "/shared"),
+		"/a": linkPage("/shared", "/b"),
+		"/b": linkPage("/shared"),
+	})
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 3, testOptions())
+
+	var shared *models.LinkStatus
+	for i := range result.Links {
+		if result.Links[i].URL == srv.URL+"/shared" {
+			shared = &result.Links[i]
+		}
+	}
+	if shared == nil {
+		t.Fatal("/shared was not checked")
+	}
+	referrers := slices.Clone(shared.Referrers)
+	slices.Sort(referrers)
+	want := []string{srv.URL + "/", srv.URL + "/a", srv.URL + "/b"}
+	if !slices.Equal(referrers, want) {
+		t.Errorf("referrers = %v, want %v", referrers, want)
+	}
+	if !slices.Contains(want, shared.ParentURL) {
+		t.Errorf("parent = %q, want one of the referrers", shared.ParentURL)
+	}
+
+	edges := map[string]int{}
+	for _, e := range BuildGraph(result.Links).Edges {
+		edges[strings.TrimPrefix(e.Source, srv.URL)+" -> "+strings.TrimPrefix(e.Target, srv.URL)] = e.Count
+	}
+	wantEdges := map[string]int{
+		"/ -> /a":       1,
+		"/ -> /shared":  2,
+		"/a -> /shared": 1,
+		"/a -> /b":      1,
+		"/b -> /shared": 1,
+	}
+	if fmt.Sprint(edges) != fmt.Sprint(wantEdges) {
+		t.Errorf("edges = %v, want %v", edges, wantEdges)
+	}
+}
+
+func TestBuildGraph(t *testing.T) {
+	links := []models.LinkStatus{
+		{URL: "https://example.com/", StatusCode: 200, IsWorking: true},
+		{
+			URL: "https://example.com/logo.png", ResourceType: ResourceImage, StatusCode: 404,
+			Sources: []models.LinkSource{
+				{ParentURL: "https://example.com/"},
+				{ParentURL: "https://example.com/about"},
+				{ParentURL: "https://example.com/"},
+			},
+		},
+		{URL: "https://example.com/private", Skipped: true, Sources: []models.LinkSource{{ParentURL: "https://example.com/"}}},
+	}
+
+	graph := BuildGraph(links)
+	wantNodes := []models.LinkNode{
+		{URL: "https://example.com/", StatusCode: 200, IsWorking: true},
+		{URL: "https://example.com/logo.png", ResourceType: ResourceImage, StatusCode: 404},
+		{URL: "https://example.com/private", Skipped: true},
+	}
+	if !slices.Equal(graph.Nodes, wantNodes) {
+		t.Errorf("nodes = %+v, want %+v", graph.Nodes, wantNodes)
+	}
+	wantEdges := []models.LinkEdge{
+		{Source: "https://example.com/", Target: "https://example.com/logo.png", ResourceType: ResourceImage, Count: 2},
+		{Source: "https://example.com/about", Target: "https://example.com/logo.png", ResourceType: ResourceImage, Count: 1},
+		{Source: "https://example.com/", Target: "https://example.com/private", Count: 1},
+	}
+	if !slices.Equal(graph.Edges, wantEdges) {
+		t.Errorf("edges = %+v, want %+v", graph.Edges, wantEdges)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	graph := models.LinkGraph{
+		Nodes: []models.LinkNode{{URL: "/"}, {URL: "/a"}, {URL: "/b"}, {URL: "/c"}},
+		Edges: []models.LinkEdge{
+			{Source: "/", Target: "/a", Count: 1},
+			{Source: "/", Target: "/b", Count: 2},
+			{Source: "/a", Target: "/b", Count: 1},
+			{Source: "/b", Target: "/c", Count: 1},
+		},
+	}
+	tests := []struct {
+		target    string
+		wantNodes []string
+		wantEdges []string
+	}{
+		{"/b", []string{"/", "/a", "/b"}, []string{"/ -> /b", "/a -> /b"}},
+		{"/c", []string{"/b", "/c"}, []string{"/b -> /c"}},
+		{"/", []string{"/"}, nil},
+		{"/missing", nil, nil},
+	}
+	for _, tt := range tests {
+		sub := Subgraph(graph, tt.target)
+		var nodes, edges []string
+		for _, n := range sub.Nodes {
+			nodes = append(nodes, n.URL)
+		}
+		for _, e := range sub.Edges {
+			edges = append(edges, e.Source+" -> "+e.Target)
+		}
+		if !slices.Equal(nodes, tt.wantNodes) || !slices.Equal(edges, tt.wantEdges) {
+			t.Errorf("Subgraph(%s) = %v %v, want %v %v", tt.target, nodes, edges, tt.wantNodes, tt.wantEdges)
+		}
+	}
+}
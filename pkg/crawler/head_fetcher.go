@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// maxDrainSize is how much of an unwanted body is read so the connection
+// can be reused; larger bodies are dropped together with their connection
+const maxDrainSize = 4 << 10
+
+// HeadFetcher checks that a URL answers without loading it as a page.
+// It issues HEAD, falls back to a single-byte GET for servers that don't
+// support HEAD and keeps connections alive between checks. The pages it
+// returns never have links, anchors or content.
+type HeadFetcher struct {
+	client *http.Client
+}
+
+// NewHeadFetcher creates a fetcher for lightweight status checks
+func NewHeadFetcher() *HeadFetcher {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 10
+	transport.IdleConnTimeout = 90 * time.Second
+	return &HeadFetcher{
+		client: &http.Client{Transport: transport},
+	}
+}
+
+// Fetch returns the status of the URL
+func (f *HeadFetcher) Fetch(ctx context.Context, url string, opts BrowserOptions) (*Page, error) {
+	ctx, cancel := withTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	resp, redirects, err := f.do(ctx, http.MethodHead, url, false)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, redirects, err = f.do(ctx, http.MethodGet, url, true)
+		// Empty bodies have no first byte to return
+		if err == nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			resp, redirects, err = f.do(ctx, http.MethodGet, url, false)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The partial content was only asked for to keep the check cheap
+	status := resp.StatusCode
+	if status == http.StatusPartialContent {
+		status = http.StatusOK
+	}
+
+	return &Page{
+		URL:        url,
+		StatusCode: status,
+		Redirects:  redirects,
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After")),
+	}, nil
+}
+
+func (f *HeadFetcher) do(ctx context.Context, method, url string, firstByte bool) (*http.Response, []models.RedirectHop, error) {
+	req, err := createRequest(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Method = method
+	req.Header.Set("Accept", "*/*")
+	if firstByte {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	client, redirects := trackRedirects(f.client)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainSize))
+	resp.Body.Close()
+	return resp, *redirects, nil
+}
+
+// Close releases idle connections held by the client
+func (f *HeadFetcher) Close() error {
+	f.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,166 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// methodLog records the method and Range header of every request by path
+type methodLog struct {
+	mu       sync.Mutex
+	requests map[string][]string
+}
+
+func (l *methodLog) add(r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	req := r.Method
+	if rng := r.Header.Get("Range"); rng != "" {
+		req += " " + rng
+	}
+	l.requests[r.URL.Path] = append(l.requests[r.URL.Path], req)
+}
+
+func (l *methodLog) get(path string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.requests[path])
+}
+
+func TestHeadFetcher(t *testing.T) {
+	log := &methodLog{requests: map[string][]string{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.add(r)
+		ranged := r.Header.Get("Range") != ""
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("hello"))
+		case "/missing":
+			http.NotFound(w, r)
+		case "/throttled":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/no-head", "/not-implemented":
+			if r.Method == http.MethodHead {
+				status := http.StatusMethodNotAllowed
+				if r.URL.Path == "/not-implemented" {
+					status = http.StatusNotImplemented
+				}
+				w.WriteHeader(status)
+				return
+			}
+			// ServeContent answers the range with 206 Partial Content
+			http.ServeContent(w, r, "page.html", time.Time{}, strings.NewReader("<html>content</html>"))
+		case "/empty":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			if ranged {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			}
+		case "/no-head-missing":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			http.NotFound(w, r)
+		default:
+			if ranged {
+				t.Errorf("unexpected ranged request to %s", r.URL.Path)
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path           string
+		wantStatus     int
+		wantRequests   []string
+		wantRetryAfter time.Duration
+	}{
+		{"/ok", 200, []string{"HEAD"}, 0},
+		{"/missing", 404, []string{"HEAD"}, 0},
+		{"/throttled", 429, []string{"HEAD"}, 7 * time.Second},
+		// 206 is only the answer to the single-byte range
+		{"/no-head", 200, []string{"HEAD", "GET bytes=0-0"}, 0},
+		{"/not-implemented", 200, []string{"HEAD", "GET bytes=0-0"}, 0},
+		// An empty body cannot satisfy the range, so it is fetched whole
+		{"/empty", 200, []string{"HEAD", "GET bytes=0-0", "GET"}, 0},
+		{"/no-head-missing", 404, []string{"HEAD", "GET bytes=0-0"}, 0},
+	}
+
+	f := NewHeadFetcher()
+	defer f.Close()
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			page, err := f.Fetch(context.Background(), srv.URL+tt.path, DefaultBrowserOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", page.StatusCode, tt.wantStatus)
+			}
+			if page.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("RetryAfter = %v, want %v", page.RetryAfter, tt.wantRetryAfter)
+			}
+			if len(page.Links) != 0 {
+				t.Errorf("links = %v, want none", page.Links)
+			}
+			if got := log.get(tt.path); !slices.Equal(got, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestLeafLinksAreCheckedWithHead(t *testing.T) {
+	log := &methodLog{requests: map[string][]string{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.add(r)
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(linkPage("/page", "/leaf")))
+		case "/page":
+			w.Write([]byte(linkPage("/leaf")))
+		case "/leaf":
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		headFirst bool
+		want      string
+	}{
+		{"head first", true, "HEAD"},
+		{"disabled", false, "GET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log.mu.Lock()
+			log.requests = map[string][]string{}
+			log.mu.Unlock()
+
+			c := NewCrawlerWithFetcher(NewHTTPFetcher())
+			defer c.Close()
+			opts := testOptions()
+			opts.HeadFirst = tt.headFirst
+			c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 1, opts)
+
+			if got := log.get("/leaf"); !slices.Equal(got, []string{tt.want}) {
+				t.Errorf("leaf requests = %v, want [%s]", got, tt.want)
+			}
+		})
+	}
+}
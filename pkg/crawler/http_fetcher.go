@@ -0,0 +1,297 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"golang.org/x/net/html"
+)
+
+// maxBodySize caps how much of a response body is scanned for links
+const maxBodySize = 10 << 20
+
+// HTTPFetcher fetches pages with net/http and tokenizes the raw HTML.
+// It does not execute JavaScript, which makes it a good fit for static sites.
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher creates a fetcher backed by a plain HTTP client
+func NewHTTPFetcher() *HTTPFetcher {
+	return &HTTPFetcher{
+		client: &http.Client{},
+	}
+}
+
+// Fetch issues a GET request for the URL and extracts links from HTML responses
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string, opts BrowserOptions) (*Page, error) {
+	req, err := createRequest(url)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	client, redirects := trackRedirects(f.client)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	page := &Page{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Redirects:  *redirects,
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	if isHTML(resp.Header.Get("Content-Type")) {
+		// Resolve relative links against the final URL after redirects
+		extractLinks(io.LimitReader(resp.Body, maxBodySize), resp.Request.URL.String(), page)
+	}
+
+	return page, nil
+}
+
+// Close releases idle connections held by the client
+func (f *HTTPFetcher) Close() error {
+	f.client.CloseIdleConnections()
+	return nil
+}
+
+func isHTML(contentType string) bool {
+	return contentType == "" || strings.Contains(contentType, "text/html") || strings.Contains(contentType, "application/xhtml")
+}
+
+func createRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identify as the crawler robots.txt rules are picked for, but accept
+	// what a browser would
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+
+	return req, nil
+}
+
+// extractLinks tokenizes the HTML body and fills in the page's anchor link
+// occurrences, the ids and names fragments can target, the sub-resources,
+// the canonical URL declared by the page and its title and text
+func extractLinks(body io.Reader, baseURL string, page *Page) {
+	links := make([]Link, 0)
+	anchors := make([]string, 0)
+	var resources []Resource
+	var canonical string
+	z := html.NewTokenizer(body)
+	loc := newLocator()
+	base, _ := url.Parse(baseURL)
+
+	// rawTag is the script, style or title element whose text is being read
+	var rawTag string
+	var title, text strings.Builder
+	size := 0
+
+	// openLink is the index of the link whose text is being collected
+	openLink := -1
+	var linkText strings.Builder
+	closeLink := func() {
+		if openLink >= 0 {
+			links[openLink].Source.Text = sourceText(linkText.String())
+			openLink = -1
+			linkText.Reset()
+		}
+	}
+
+	for {
+		tt := z.Next()
+		raw := z.Raw()
+		size += len(raw)
+		loc.advance(raw)
+		switch tt {
+		case html.ErrorToken:
+			closeLink()
+			page.Links = links
+			page.Anchors = anchors
+			page.Resources = resources
+			page.Canonical = canonical
+			page.Content = &PageContent{
+				Title: strings.Join(strings.Fields(title.String()), " "),
+				Text:  strings.Join(strings.Fields(text.String()), " "),
+				Size:  size,
+			}
+			return
+		case html.TextToken:
+			switch rawTag {
+			case "title":
+				title.Write(z.Text())
+			case "":
+				t := z.Text()
+				if openLink >= 0 {
+					linkText.Write(t)
+				}
+				if text.Len() < maxContentText {
+					text.WriteString(" ")
+					text.Write(t)
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) == "a" {
+				closeLink()
+			}
+			if string(name) == rawTag {
+				rawTag = ""
+			}
+			loc.end(string(name))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			selector := loc.start(token, tt == html.SelfClosingTagToken)
+			switch token.Data {
+			case "script", "style", "noscript", "title":
+				if tt == html.StartTagToken {
+					rawTag = token.Data
+				}
+			}
+			source := models.LinkSource{
+				Title:     attrValue(token, "title"),
+				AriaLabel: attrValue(token, "aria-label"),
+				Selector:  selector,
+				Line:      loc.line,
+				Column:    loc.column,
+			}
+
+			// Honor <base href> for resolving relative links
+			if token.Data == "base" {
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						if ref, err := url.Parse(attr.Val); err == nil {
+							base = base.ResolveReference(ref)
+						}
+					}
+				}
+			}
+			if token.Data == "link" && canonical == "" && attrValue(token, "rel") == "canonical" {
+				if href := attrValue(token, "href"); href != "" {
+					canonical, _ = resolveURL(base, href)
+				}
+			}
+			if id := attrValue(token, "id"); id != "" {
+				anchors = append(anchors, id)
+			}
+			// Images inside a link stand in for its text
+			if token.Data == "img" && openLink >= 0 {
+				linkText.WriteString(" " + attrValue(token, "alt"))
+			}
+			if token.Data == "a" {
+				closeLink()
+				if name := attrValue(token, "name"); name != "" {
+					anchors = append(anchors, name)
+				}
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						// Same-page "#section" links are kept so their anchor gets checked
+						link := strings.TrimSpace(attr.Val)
+						if link == "#" || link == "" || strings.HasPrefix(link, "mailto:") ||
+							strings.HasPrefix(link, "tel:") || strings.HasPrefix(link, "javascript:") {
+							continue
+						}
+
+						absoluteURL, err := resolveURL(base, link)
+						if err != nil {
+							continue
+						}
+
+						if strings.HasPrefix(absoluteURL, "http") {
+							links = append(links, Link{URL: absoluteURL, Source: source})
+							if tt == html.StartTagToken {
+								openLink = len(links) - 1
+							}
+						}
+					}
+				}
+			}
+			for _, r := range tokenResources(token) {
+				absoluteURL, err := resolveURL(base, r.URL)
+				if err != nil || !strings.HasPrefix(absoluteURL, "http") {
+					continue
+				}
+				r.URL = absoluteURL
+				r.Source = source
+				if token.Data == "img" {
+					r.Source.Text = sourceText(attrValue(token, "alt"))
+				}
+				resources = append(resources, r)
+			}
+		}
+	}
+}
+
+// tokenResources returns the raw sub-resource references of a start tag
+func tokenResources(token html.Token) []Resource {
+	var refs []Resource
+	add := func(key, resourceType string) {
+		if v := attrValue(token, key); v != "" {
+			refs = append(refs, Resource{URL: v, Type: resourceType})
+		}
+	}
+	addSrcset := func(resourceType string) {
+		for _, u := range parseSrcset(attrValue(token, "srcset")) {
+			refs = append(refs, Resource{URL: u, Type: resourceType})
+		}
+	}
+
+	switch token.Data {
+	case "img":
+		add("src", ResourceImage)
+		addSrcset(ResourceImage)
+	case "source":
+		// <picture> sources use srcset, <video> and <audio> sources use src
+		addSrcset(ResourceImage)
+		add("src", ResourceMedia)
+	case "script":
+		add("src", ResourceScript)
+	case "link":
+		if slices.Contains(strings.Fields(strings.ToLower(attrValue(token, "rel"))), "stylesheet") {
+			add("href", ResourceStylesheet)
+		}
+	case "iframe":
+		add("src", ResourceIframe)
+	case "video":
+		add("src", ResourceMedia)
+		add("poster", ResourceMedia)
+	case "audio", "track":
+		add("src", ResourceMedia)
+	}
+	return refs
+}
+
+func attrValue(token html.Token, key string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
+
+func resolveURL(base *url.URL, ref string) (string, error) {
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	resolvedURL := base.ResolveReference(refURL)
+	return resolvedURL.String(), nil
+}
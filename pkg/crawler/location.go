@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// maxSourceText caps the anchor text kept for a link occurrence
+const maxSourceText = 200
+
+// voidElements never have children or an end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// impliedEnd are elements whose open tag implicitly closes an open sibling of the same name
+var impliedEnd = map[string]bool{
+	"li": true, "p": true, "option": true, "dt": true, "dd": true, "tr": true, "td": true, "th": true,
+}
+
+// uniqueElements appear once per document and need no position in a selector
+var uniqueElements = map[string]bool{"html": true, "head": true, "body": true}
+
+// cssIdent matches ids that can be used in a selector without escaping
+var cssIdent = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// locator tracks the line/column and element path of the tokens read from
+// an html.Tokenizer so link occurrences can be pointed at in the source
+type locator struct {
+	line, column int // Position of the current token, 1-based
+	nextLine     int
+	nextColumn   int
+	stack        []*element
+}
+
+type element struct {
+	selector string // tag with its id or :nth-of-type() position
+	counts   map[string]int
+}
+
+func newLocator() *locator {
+	return &locator{
+		nextLine:   1,
+		nextColumn: 1,
+		stack:      []*element{{counts: make(map[string]int)}},
+	}
+}
+
+// advance moves past the raw text of the token just read by the tokenizer.
+// It must be called after Next and before Token, which reuses the buffer.
+func (l *locator) advance(raw []byte) {
+	l.line, l.column = l.nextLine, l.nextColumn
+	if n := bytes.Count(raw, []byte("\n")); n > 0 {
+		l.nextLine += n
+		l.nextColumn = 1 + utf8.RuneCount(raw[bytes.LastIndexByte(raw, '\n')+1:])
+	} else {
+		l.nextColumn += utf8.RuneCount(raw)
+	}
+}
+
+// start records an opening tag and returns its selector path
+func (l *locator) start(token html.Token, selfClosing bool) string {
+	if impliedEnd[token.Data] && len(l.stack) > 1 && l.top().tag() == token.Data {
+		l.stack = l.stack[:len(l.stack)-1]
+	}
+
+	parent := l.top()
+	parent.counts[token.Data]++
+	el := &element{selector: fmt.Sprintf("%s:nth-of-type(%d)", token.Data, parent.counts[token.Data])}
+	if id := attrValue(token, "id"); cssIdent.MatchString(id) {
+		el.selector = token.Data + "#" + id
+	} else if uniqueElements[token.Data] {
+		el.selector = token.Data
+	}
+
+	path := l.path(el)
+	if !selfClosing && !voidElements[token.Data] {
+		el.counts = make(map[string]int)
+		l.stack = append(l.stack, el)
+	}
+	return path
+}
+
+// end closes the innermost open element named tag, if there is one
+func (l *locator) end(tag string) {
+	for i := len(l.stack) - 1; i > 0; i-- {
+		if l.stack[i].tag() == tag {
+			l.stack = l.stack[:i]
+			return
+		}
+	}
+}
+
+func (l *locator) top() *element {
+	return l.stack[len(l.stack)-1]
+}
+
+// path builds the selector of el, starting at its nearest ancestor with an id
+func (l *locator) path(el *element) string {
+	parts := []string{el.selector}
+	for i := len(l.stack) - 1; i > 0 && !strings.Contains(parts[0], "#"); i-- {
+		parts = append([]string{l.stack[i].selector}, parts...)
+	}
+	return strings.Join(parts, " > ")
+}
+
+func (e *element) tag() string {
+	tag, _, _ := strings.Cut(e.selector, ":")
+	tag, _, _ = strings.Cut(tag, "#")
+	return tag
+}
+
+// sourceText collapses whitespace and truncates text kept for a link
+func sourceText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > maxSourceText {
+		text = string([]rune(text)[:maxSourceText]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+func TestLinkSources(t *testing.T) {
+	const body = `<html>
+<body>
+<nav id="menu"><a href="/a">Home</a> <a href="/b" title="B page">B</a></nav>
+<main>
+  <p>Intro <a href="/a">again</a></p>
+  <p><a href="/c" aria-label="Cee"><img src="c.png" alt="C icon"></a></p>
+  <ul><li><a href="/d">one</a><li><a href="/e">   two
+  lines </a></ul>
+  <p id="9bad"><a href="/f">ñ</a> <a href="/g">x</a></p>
+</main>
+</body></html>`
+
+	page := &Page{}
+	extractLinks(strings.NewReader(body), "https://example.com/", page)
+
+	const main = "html > body > main:nth-of-type(1) > "
+	want := []models.LinkSource{
+		{Text: "Home", Selector: "nav#menu > a:nth-of-type(1)", Line: 3, Column: 16},
+		{Text: "B", Title: "B page", Selector: "nav#menu > a:nth-of-type(2)", Line: 3, Column: 38},
+		{Text: "again", Selector: main + "p:nth-of-type(1) > a:nth-of-type(1)", Line: 5, Column: 12},
+		{Text: "C icon", AriaLabel: "Cee", Selector: main + "p:nth-of-type(2) > a:nth-of-type(1)", Line: 6, Column: 6},
+		{Text: "one", Selector: main + "ul:nth-of-type(1) > li:nth-of-type(1) > a:nth-of-type(1)", Line: 7, Column: 11},
+		// The second <li> implicitly closes the first one
+		{Text: "two lines", Selector: main + "ul:nth-of-type(1) > li:nth-of-type(2) > a:nth-of-type(1)", Line: 7, Column: 35},
+		// Ids that need escaping are not used; columns count characters, not bytes
+		{Text: "ñ", Selector: main + "p:nth-of-type(3) > a:nth-of-type(1)", Line: 9, Column: 16},
+		{Text: "x", Selector: main + "p:nth-of-type(3) > a:nth-of-type(2)", Line: 9, Column: 35},
+	}
+	if len(page.Links) != len(want) {
+		t.Fatalf("got %d links, want %d", len(page.Links), len(want))
+	}
+	for i, l := range page.Links {
+		if l.Source != want[i] {
+			t.Errorf("link %d (%s) source = %+v, want %+v", i, l.URL, l.Source, want[i])
+		}
+	}
+}
+
+func TestSourceText(t *testing.T) {
+	long := strings.Repeat("a", maxSourceText+10)
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"  Read\n   the   docs ", "Read the docs"},
+		{"", ""},
+		{long, long[:maxSourceText] + "…"},
+	}
+	for _, tt := range tests {
+		if got := sourceText(tt.text); got != tt.want {
+			t.Errorf("sourceText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSourcesListEveryOccurrence(t *testing.T) {
+	srv := serveSite(t, map[string]string{
+		"/":  linkPage("/a", "/b", "/b"),
+		"/a": linkPage("/b"),
+		"/b": linkPage(),
+	})
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 3, testOptions())
+
+	for _, l := range result.Links {
+		if l.URL != srv.URL+"/b" {
+			continue
+		}
+		if len(l.Sources) != 3 {
+			t.Errorf("sources = %+v, want the 3 occurrences", l.Sources)
+		}
+		if len(l.Referrers) != 2 {
+			t.Errorf("referrers = %v, want / and /a", l.Referrers)
+		}
+		return
+	}
+	t.Fatal("/b was not checked")
+}
@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/purell"
+)
+
+// Normalization rule names accepted by NormalizeRules
+const (
+	RuleStripFragment       = "strip_fragment"
+	RuleSortQuery           = "sort_query"
+	RuleDropTracking        = "drop_tracking"
+	RuleLowercaseHost       = "lowercase_host"
+	RuleRemoveDefaultPort   = "remove_default_port"
+	RuleRemoveTrailingSlash = "remove_trailing_slash"
+	RuleHonorCanonical      = "honor_canonical"
+	RuleNone                = "none"
+)
+
+// trackingParams are query parameters that never change the page content
+var trackingParams = []string{
+	"utm_*", "gclid", "dclid", "fbclid", "msclkid", "yclid", "mc_cid", "mc_eid", "_ga", "_hsenc", "_hsmi", "ref_src",
+}
+
+// NormalizeOptions selects the rules used to map URL variants to one
+// canonical form before deduplication
+type NormalizeOptions struct {
+	StripFragment       bool
+	SortQuery           bool
+	DropTrackingParams  bool
+	LowercaseHost       bool
+	RemoveDefaultPort   bool
+	RemoveTrailingSlash bool
+	// HonorCanonical treats pages declaring the same <link rel=canonical>
+	// as duplicates whose links are only followed once
+	HonorCanonical bool
+}
+
+// DefaultNormalizeOptions returns the rules that don't change which resource
+// a URL points to on common sites, where /a and /a/ serve the same page
+func DefaultNormalizeOptions() NormalizeOptions {
+	return NormalizeOptions{
+		StripFragment:       true,
+		SortQuery:           true,
+		DropTrackingParams:  true,
+		LowercaseHost:       true,
+		RemoveDefaultPort:   true,
+		RemoveTrailingSlash: true,
+	}
+}
+
+// NormalizeRules builds normalization options from rule names. An empty
+// list yields the defaults and "none" disables every rule.
+func NormalizeRules(names []string) (NormalizeOptions, error) {
+	if len(names) == 0 {
+		return DefaultNormalizeOptions(), nil
+	}
+
+	var opts NormalizeOptions
+	for _, name := range names {
+		switch name {
+		case RuleStripFragment:
+			opts.StripFragment = true
+		case RuleSortQuery:
+			opts.SortQuery = true
+		case RuleDropTracking:
+			opts.DropTrackingParams = true
+		case RuleLowercaseHost:
+			opts.LowercaseHost = true
+		case RuleRemoveDefaultPort:
+			opts.RemoveDefaultPort = true
+		case RuleRemoveTrailingSlash:
+			opts.RemoveTrailingSlash = true
+		case RuleHonorCanonical:
+			opts.HonorCanonical = true
+		case RuleNone:
+		default:
+			return opts, fmt.Errorf("unknown normalization rule %q", name)
+		}
+	}
+	return opts, nil
+}
+
+// Normalize returns the canonical form of rawURL; URLs that cannot be
+// parsed are returned unchanged
+func (o NormalizeOptions) Normalize(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	if o.DropTrackingParams && u.RawQuery != "" {
+		u.RawQuery = dropTrackingParams(u.RawQuery)
+	}
+
+	flags := purell.FlagLowercaseScheme | purell.FlagRemoveEmptyQuerySeparator
+	if o.StripFragment {
+		flags |= purell.FlagRemoveFragment
+	}
+	if o.SortQuery {
+		flags |= purell.FlagSortQuery
+	}
+	if o.LowercaseHost {
+		flags |= purell.FlagLowercaseHost
+	}
+	if o.RemoveDefaultPort {
+		flags |= purell.FlagRemoveDefaultPort
+	}
+	// purell.FlagRemoveTrailingSlash would also strip the root path, turning
+	// https://example.com/ into https://example.com
+	if o.RemoveTrailingSlash && len(u.Path) > 1 {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	}
+
+	return purell.NormalizeURL(u, flags)
+}
+
+// dropTrackingParams removes tracking parameters while keeping the order of the rest
+func dropTrackingParams(rawQuery string) string {
+	parts := strings.Split(rawQuery, "&")
+	kept := parts[:0]
+	for _, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		if key, err := url.QueryUnescape(key); err == nil && isTrackingParam(key) {
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, "&")
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	for _, p := range trackingParams {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
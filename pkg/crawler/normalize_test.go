@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	defaults := DefaultNormalizeOptions()
+	tests := []struct {
+		name string
+		opts NormalizeOptions
+		url  string
+		want string
+	}{
+		{"fragment", defaults, "https://example.com/a#intro", "https://example.com/a"},
+		{"query order", defaults, "https://example.com/a?b=2&a=1", "https://example.com/a?a=1&b=2"},
+		{"tracking params", defaults, "https://example.com/a?utm_source=x&id=3&fbclid=y&UTM_Medium=z", "https://example.com/a?id=3"},
+		{"only tracking params", defaults, "https://example.com/a?gclid=1", "https://example.com/a"},
+		{"host case", defaults, "HTTPS://Example.COM/Path", "https://example.com/Path"},
+		{"default https port", defaults, "https://example.com:443/a", "https://example.com/a"},
+		{"default http port", defaults, "http://example.com:80/a", "http://example.com/a"},
+		{"other port", defaults, "https://example.com:8443/a", "https://example.com:8443/a"},
+		{"trailing slash", defaults, "https://example.com/a/", "https://example.com/a"},
+		{"root slash kept", defaults, "https://example.com/", "https://example.com/"},
+		{"trailing slash kept", NormalizeOptions{StripFragment: true}, "https://example.com/a/", "https://example.com/a/"},
+		{"all variants", defaults, "https://X.com:443/a/?utm_source=news#frag", "https://x.com/a"},
+		{"no rules", NormalizeOptions{}, "https://Example.com:443/a?b=2&a=1&utm_source=x#top", "https://Example.com:443/a?b=2&a=1&utm_source=x#top"},
+		{"unparsable", defaults, "http://[::1", "http://[::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Normalize(tt.url); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []string
+		want    NormalizeOptions
+		wantErr bool
+	}{
+		{"defaults", nil, DefaultNormalizeOptions(), false},
+		{"none", []string{RuleNone}, NormalizeOptions{}, false},
+		{"selected", []string{RuleStripFragment, RuleRemoveTrailingSlash, RuleHonorCanonical},
+			NormalizeOptions{StripFragment: true, RemoveTrailingSlash: true, HonorCanonical: true}, false},
+		{"unknown", []string{"lowercase_path"}, NormalizeOptions{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeRules(tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeRules(%v) error = %v, want error %v", tt.rules, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("NormalizeRules(%v) = %+v, want %+v", tt.rules, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVariantsAreCheckedOnce(t *testing.T) {
+	srv := serveSite(t, map[string]string{
+		"/":  linkPage("/a?x=1&y=2", "/a?y=2&x=1", "/a?x=1&y=2&utm_source=mail", "/a?x=1&y=2#part", "/a/?x=1&y=2"),
+		"/a": `<html><body><div id="part"></div></body></html>`,
+	})
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 2, testOptions())
+
+	var checked []string
+	for _, l := range result.Links {
+		if strings.Contains(l.URL, "/a") {
+			checked = append(checked, l.URL)
+			if len(l.Variants) != 5 {
+				t.Errorf("variants = %v, want the 5 raw links", l.Variants)
+			}
+		}
+	}
+	if len(checked) != 1 || checked[0] != srv.URL+"/a?x=1&y=2" {
+		t.Errorf("checked %v, want only %s/a?x=1&y=2", checked, srv.URL)
+	}
+}
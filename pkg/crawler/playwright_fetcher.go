@@ -0,0 +1,324 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/playwright-community/playwright-go"
+)
+
+// PlaywrightFetcher renders pages in a headless Chromium instance
+type PlaywrightFetcher struct {
+	pw      *playwright.Playwright
+	browser playwright.Browser
+}
+
+// NewPlaywrightFetcher installs the Playwright driver and launches Chromium
+func NewPlaywrightFetcher() (*PlaywrightFetcher, error) {
+	// Install the Playwright driver and only the browser we actually launch
+	err := playwright.Install(&playwright.RunOptions{Browsers: []string{"chromium"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to install Playwright driver: %v", err)
+	}
+
+	pw, err := playwright.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run Playwright: %v", err)
+	}
+
+	// Try with various browser options
+	browserOpts := []playwright.BrowserTypeLaunchOptions{
+		// Default options
+		{
+			Headless: playwright.Bool(true),
+		},
+		// No sandbox
+		{
+			Headless: playwright.Bool(true),
+			Args:     []string{"--no-sandbox", "--disable-setuid-sandbox"},
+		},
+		// No sandbox + other options
+		{
+			Headless: playwright.Bool(true),
+			Args:     []string{"--no-sandbox", "--disable-setuid-sandbox", "--disable-dev-shm-usage"},
+		},
+	}
+
+	var browser playwright.Browser
+	var launchErr error
+
+	for _, opts := range browserOpts {
+		browser, launchErr = pw.Chromium.Launch(opts)
+		if launchErr == nil {
+			break
+		}
+		log.Printf("Failed to launch browser with options %+v: %v", opts, launchErr)
+	}
+
+	if launchErr != nil {
+		pw.Stop()
+		return nil, fmt.Errorf("all browser launch attempts failed: %v", launchErr)
+	}
+
+	return &PlaywrightFetcher{
+		pw:      pw,
+		browser: browser,
+	}, nil
+}
+
+// Fetch opens the URL in a fresh browser context and collects its links
+func (f *PlaywrightFetcher) Fetch(ctx context.Context, url string, opts BrowserOptions) (*Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Create a new context for this page
+	browserContext, err := f.browser.NewContext(playwright.BrowserNewContextOptions{
+		UserAgent: playwright.String(UserAgent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating context: %v", err)
+	}
+	defer browserContext.Close()
+
+	// Playwright calls don't take a context, so closing the browser
+	// context is what aborts a navigation in progress
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			browserContext.Close()
+		case <-done:
+		}
+	}()
+
+	// Create a new page
+	page, err := browserContext.NewPage()
+	if err != nil {
+		return nil, fmt.Errorf("error creating page: %v", err)
+	}
+
+	resp, err := page.Goto(url, playwright.PageGotoOptions{
+		WaitUntil: waitUntilState(opts.WaitUntil),
+		Timeout:   playwright.Float(float64(opts.Timeout.Milliseconds())),
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Chromium gives up on loops after 20 redirects
+		if strings.Contains(err.Error(), "ERR_TOO_MANY_REDIRECTS") {
+			return nil, &RedirectError{Loop: true}
+		}
+		return nil, err
+	}
+
+	result := &Page{
+		URL:        url,
+		StatusCode: resp.Status(),
+		Redirects:  redirectChain(resp.Request()),
+		RetryAfter: ParseRetryAfter(resp.Headers()["retry-after"]),
+	}
+
+	// Extract links using JavaScript
+	if err := extractLinksFromPage(page, result); err != nil {
+		log.Printf("Error extracting links from %s: %v\n", url, err)
+	}
+
+	return result, nil
+}
+
+// Close shuts down the browser and the Playwright driver
+func (f *PlaywrightFetcher) Close() error {
+	if f.browser != nil {
+		if err := f.browser.Close(); err != nil {
+			return err
+		}
+	}
+	if f.pw != nil {
+		return f.pw.Stop()
+	}
+	return nil
+}
+
+// redirectChain walks back from the final request of a navigation and
+// returns the redirect hops that led to it
+func redirectChain(final playwright.Request) []models.RedirectHop {
+	var hops []models.RedirectHop
+	location := final.URL()
+	for req := final.RedirectedFrom(); req != nil; req = req.RedirectedFrom() {
+		hop := models.RedirectHop{URL: req.URL(), Location: location}
+		if resp, err := req.Response(); err == nil && resp != nil {
+			hop.StatusCode = resp.Status()
+		}
+		hops = append([]models.RedirectHop{hop}, hops...)
+		location = req.URL()
+	}
+	return hops
+}
+
+func waitUntilState(waitUntil string) *playwright.WaitUntilState {
+	switch waitUntil {
+	case WaitUntilLoad:
+		return playwright.WaitUntilStateLoad
+	case WaitUntilDOMContentLoaded:
+		return playwright.WaitUntilStateDomcontentloaded
+	default:
+		return playwright.WaitUntilStateNetworkidle
+	}
+}
+
+// extractLinksFromPage fills in the link occurrences, anchor targets,
+// sub-resources, declared canonical URL and content of the rendered page
+func extractLinksFromPage(page playwright.Page, result *Page) error {
+	// Execute JavaScript to get all link occurrences, anchor targets, sub-resources, the declared canonical URL and the page content
+	extracted, err := page.Evaluate(fmt.Sprintf(`() => {
+		const clip = text => {
+			text = (text || '').replace(/\s+/g, ' ').trim();
+			return text.length > 200 ? text.slice(0, 200) + '…' : text;
+		};
+		// CSS selector path from the nearest ancestor with a usable id
+		const selector = el => {
+			const parts = [];
+			for (; el && el.nodeType === Node.ELEMENT_NODE; el = el.parentElement) {
+				const tag = el.localName;
+				if (el.id && /^[A-Za-z][A-Za-z0-9_-]*$/.test(el.id)) {
+					parts.unshift(tag + '#' + el.id);
+					break;
+				}
+				if (tag === 'html' || tag === 'head' || tag === 'body') {
+					parts.unshift(tag);
+					continue;
+				}
+				let n = 1;
+				for (let sib = el.previousElementSibling; sib; sib = sib.previousElementSibling) {
+					if (sib.localName === tag) n++;
+				}
+				parts.unshift(tag + ':nth-of-type(' + n + ')');
+			}
+			return parts.join(' > ');
+		};
+		const source = (el, text) => ({
+			text: clip(text),
+			title: el.getAttribute('title') || '',
+			ariaLabel: el.getAttribute('aria-label') || '',
+			selector: selector(el),
+		});
+
+		const links = [];
+		document.querySelectorAll('a[href]').forEach(el => {
+			const href = el.href;
+			if (href && el.getAttribute('href').trim() !== '#' && !href.startsWith('javascript:') &&
+				!href.startsWith('mailto:') && !href.startsWith('tel:')) {
+				const img = el.querySelector('img[alt]');
+				links.push({ url: href, ...source(el, el.innerText || el.textContent || (img ? img.alt : '')) });
+			}
+		});
+
+		const anchors = new Set();
+		document.querySelectorAll('[id]').forEach(el => { if (el.id) anchors.add(el.id); });
+		document.querySelectorAll('a[name]').forEach(el => anchors.add(el.getAttribute('name')));
+
+		const resources = [];
+		const add = (el, ref, type) => {
+			if (!ref) return;
+			try {
+				const url = new URL(ref.trim(), document.baseURI).href;
+				if (url.startsWith('http')) resources.push({ url, type, ...source(el, el.getAttribute('alt')) });
+			} catch (e) {}
+		};
+		const addSrcset = (el, type) => {
+			(el.getAttribute('srcset') || '').split(/,\s+/).forEach(c => add(el, c.trim().split(/\s+/)[0], type));
+		};
+		const attr = (el, name) => el.getAttribute(name);
+		document.querySelectorAll('img').forEach(el => { add(el, attr(el, 'src'), 'image'); addSrcset(el, 'image'); });
+		document.querySelectorAll('source').forEach(el => { addSrcset(el, 'image'); add(el, attr(el, 'src'), 'media'); });
+		document.querySelectorAll('script[src]').forEach(el => add(el, attr(el, 'src'), 'script'));
+		document.querySelectorAll('link[href]').forEach(el => {
+			if (el.relList.contains('stylesheet')) add(el, attr(el, 'href'), 'stylesheet');
+		});
+		document.querySelectorAll('iframe[src]').forEach(el => add(el, attr(el, 'src'), 'iframe'));
+		document.querySelectorAll('video').forEach(el => { add(el, attr(el, 'src'), 'media'); add(el, attr(el, 'poster'), 'media'); });
+		document.querySelectorAll('audio[src], track[src]').forEach(el => add(el, attr(el, 'src'), 'media'));
+
+		const canonical = document.querySelector('link[rel="canonical"][href]');
+		return {
+			links,
+			anchors: Array.from(anchors),
+			resources,
+			canonical: canonical ? canonical.href : '',
+			title: document.title,
+			text: document.body ? document.body.innerText.replace(/\s+/g, ' ').trim().slice(0, %d) : '',
+			size: document.documentElement.outerHTML.length,
+		};
+	}`, maxContentText))
+	if err != nil {
+		return err
+	}
+
+	obj, _ := extracted.(map[string]interface{})
+
+	// Convert the interface{} to []Link
+	var links []Link
+	if linksArr, ok := obj["links"].([]interface{}); ok {
+		for _, link := range linksArr {
+			if m, ok := link.(map[string]interface{}); ok {
+				u, _ := m["url"].(string)
+				links = append(links, Link{URL: u, Source: evaluatedSource(m)})
+			}
+		}
+	}
+
+	// A rendered page always has an anchor set, even an empty one
+	anchors := []string{}
+	if anchorsArr, ok := obj["anchors"].([]interface{}); ok {
+		for _, a := range anchorsArr {
+			if name, ok := a.(string); ok {
+				anchors = append(anchors, name)
+			}
+		}
+	}
+
+	var resources []Resource
+	if resourcesArr, ok := obj["resources"].([]interface{}); ok {
+		for _, r := range resourcesArr {
+			if m, ok := r.(map[string]interface{}); ok {
+				u, _ := m["url"].(string)
+				t, _ := m["type"].(string)
+				resources = append(resources, Resource{URL: u, Type: t, Source: evaluatedSource(m)})
+			}
+		}
+	}
+	canonical, _ := obj["canonical"].(string)
+
+	content := &PageContent{}
+	content.Title, _ = obj["title"].(string)
+	content.Text, _ = obj["text"].(string)
+	switch size := obj["size"].(type) {
+	case int:
+		content.Size = size
+	case float64:
+		content.Size = int(size)
+	}
+
+	result.Links = links
+	result.Anchors = anchors
+	result.Resources = resources
+	result.Canonical = canonical
+	result.Content = content
+	return nil
+}
+
+// evaluatedSource reads the location fields of a link collected in the page
+func evaluatedSource(m map[string]interface{}) models.LinkSource {
+	var source models.LinkSource
+	source.Text, _ = m["text"].(string)
+	source.Title, _ = m["title"].(string)
+	source.AriaLabel, _ = m["ariaLabel"].(string)
+	source.Selector, _ = m["selector"].(string)
+	return source
+}
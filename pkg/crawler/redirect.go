@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// maxRedirectHops is how many redirects are followed before giving up,
+// independently of the chain length that gets flagged
+const maxRedirectHops = 20
+
+// Redirect flags reported on a LinkStatus
+const (
+	RedirectPermanent   = "permanent"       // The link itself is permanently moved and should be updated
+	RedirectDowngrade   = "https_downgrade" // A hop leads from HTTPS to HTTP
+	RedirectCrossDomain = "cross_domain"    // The chain ends on another registrable domain
+	RedirectLongChain   = "long_chain"      // The chain has more hops than the configured limit
+)
+
+// RedirectError is returned when a redirect chain loops or never ends
+type RedirectError struct {
+	Hops []models.RedirectHop
+	Loop bool
+}
+
+func (e *RedirectError) Error() string {
+	if !e.Loop {
+		return fmt.Sprintf("stopped after %d redirects", len(e.Hops))
+	}
+	if len(e.Hops) == 0 {
+		return "redirect loop"
+	}
+	chain := make([]string, 0, len(e.Hops)+1)
+	for _, hop := range e.Hops {
+		chain = append(chain, hop.URL)
+	}
+	chain = append(chain, e.Hops[len(e.Hops)-1].Location)
+	return "redirect loop: " + strings.Join(chain, " -> ")
+}
+
+// trackRedirects returns a copy of client that records every redirect hop
+// and fails with a RedirectError on loops instead of running into a timeout
+func trackRedirects(client *http.Client) (*http.Client, *[]models.RedirectHop) {
+	hops := &[]models.RedirectHop{}
+	tracked := *client
+	tracked.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if resp := req.Response; resp != nil {
+			*hops = append(*hops, models.RedirectHop{
+				URL:        resp.Request.URL.String(),
+				StatusCode: resp.StatusCode,
+				Location:   req.URL.String(),
+			})
+		}
+		for _, prev := range via {
+			if prev.URL.String() == req.URL.String() {
+				return &RedirectError{Hops: *hops, Loop: true}
+			}
+		}
+		if len(via) >= maxRedirectHops {
+			return &RedirectError{Hops: *hops}
+		}
+		return nil
+	}
+	return &tracked, hops
+}
+
+// redirectFlags returns the redirect policy problems of a chain that
+// started at rawURL; maxHops of zero disables the chain length check
+func redirectFlags(rawURL string, hops []models.RedirectHop, maxHops int) []string {
+	if len(hops) == 0 {
+		return nil
+	}
+
+	var flags []string
+	if code := hops[0].StatusCode; code == http.StatusMovedPermanently || code == http.StatusPermanentRedirect {
+		flags = append(flags, RedirectPermanent)
+	}
+	for _, hop := range hops {
+		if strings.HasPrefix(hop.URL, "https:") && strings.HasPrefix(hop.Location, "http:") {
+			flags = append(flags, RedirectDowngrade)
+			break
+		}
+	}
+	from, errFrom := url.Parse(rawURL)
+	to, errTo := url.Parse(hops[len(hops)-1].Location)
+	if errFrom == nil && errTo == nil &&
+		registrableDomain(strings.ToLower(from.Hostname())) != registrableDomain(strings.ToLower(to.Hostname())) {
+		flags = append(flags, RedirectCrossDomain)
+	}
+	if maxHops > 0 && len(hops) > maxHops {
+		flags = append(flags, RedirectLongChain)
+	}
+	return flags
+}
@@ -0,0 +1,154 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+func TestRedirectFlags(t *testing.T) {
+	hop := func(from string, code int, to string) models.RedirectHop {
+		return models.RedirectHop{URL: from, StatusCode: code, Location: to}
+	}
+	tests := []struct {
+		name    string
+		url     string
+		hops    []models.RedirectHop
+		maxHops int
+		want    []string
+	}{
+		{"no redirect", "https://a.com/", nil, 3, nil},
+		{"temporary", "https://a.com/x", []models.RedirectHop{hop("https://a.com/x", 302, "https://a.com/y")}, 3, nil},
+		{"moved permanently", "https://a.com/x", []models.RedirectHop{hop("https://a.com/x", 301, "https://a.com/y")}, 3, []string{RedirectPermanent}},
+		{"permanent redirect", "https://a.com/x", []models.RedirectHop{hop("https://a.com/x", 308, "https://a.com/y")}, 3, []string{RedirectPermanent}},
+		{"only the first hop counts as permanent", "https://a.com/x", []models.RedirectHop{
+			hop("https://a.com/x", 302, "https://a.com/y"), hop("https://a.com/y", 301, "https://a.com/z"),
+		}, 3, nil},
+		{"downgrade", "https://a.com/x", []models.RedirectHop{hop("https://a.com/x", 302, "http://a.com/x")}, 3, []string{RedirectDowngrade}},
+		{"upgrade", "http://a.com/x", []models.RedirectHop{hop("http://a.com/x", 302, "https://a.com/x")}, 3, nil},
+		{"subdomain", "https://a.com/x", []models.RedirectHop{hop("https://a.com/x", 302, "https://www.a.com/x")}, 3, nil},
+		{"cross domain", "https://a.com/x", []models.RedirectHop{hop("https://a.com/x", 302, "https://b.org/x")}, 3, []string{RedirectCrossDomain}},
+		{"long chain", "https://a.com/1", []models.RedirectHop{
+			hop("https://a.com/1", 302, "https://a.com/2"), hop("https://a.com/2", 302, "https://a.com/3"),
+		}, 1, []string{RedirectLongChain}},
+		{"chain at the limit", "https://a.com/1", []models.RedirectHop{
+			hop("https://a.com/1", 302, "https://a.com/2"), hop("https://a.com/2", 302, "https://a.com/3"),
+		}, 2, nil},
+		{"chain check disabled", "https://a.com/1", []models.RedirectHop{
+			hop("https://a.com/1", 302, "https://a.com/2"), hop("https://a.com/2", 302, "https://a.com/3"),
+		}, 0, nil},
+		{"every flag", "https://a.com/1", []models.RedirectHop{
+			hop("https://a.com/1", 301, "http://a.com/1"), hop("http://a.com/1", 302, "http://b.org/"),
+		}, 1, []string{RedirectPermanent, RedirectDowngrade, RedirectCrossDomain, RedirectLongChain}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redirectFlags(tt.url, tt.hops, tt.maxHops); !slices.Equal(got, tt.want) {
+				t.Errorf("redirectFlags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// redirectServer serves /loop/a and /loop/b redirecting to each other,
+// /endless/N redirecting to /endless/N+1 and /chain/N redirecting down to /chain/0
+func redirectServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if endless, ok := strings.CutPrefix(r.URL.Path, "/endless/"); ok {
+			n, _ := strconv.Atoi(endless)
+			http.Redirect(w, r, fmt.Sprintf("/endless/%d", n+1), http.StatusFound)
+			return
+		}
+		if chain, ok := strings.CutPrefix(r.URL.Path, "/chain/"); ok && chain != "0" {
+			n, _ := strconv.Atoi(chain)
+			http.Redirect(w, r, fmt.Sprintf("/chain/%d", n-1), http.StatusMovedPermanently)
+			return
+		}
+		switch r.URL.Path {
+		case "/loop/a":
+			http.Redirect(w, r, "/loop/b", http.StatusFound)
+		case "/loop/b":
+			http.Redirect(w, r, "/loop/a", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, linkPage())
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRedirectLoops(t *testing.T) {
+	srv := redirectServer(t)
+	tests := []struct {
+		name     string
+		path     string
+		wantLoop bool
+		wantHops int
+	}{
+		{"loop", "/loop/a", true, 2},
+		{"endless", "/endless/0", false, maxRedirectHops},
+	}
+	fetchers := []struct {
+		name    string
+		fetcher Fetcher
+	}{
+		{"http", NewHTTPFetcher()},
+		{"head", NewHeadFetcher()},
+	}
+	for _, f := range fetchers {
+		defer f.fetcher.Close()
+		for _, tt := range tests {
+			t.Run(f.name+" "+tt.name, func(t *testing.T) {
+				_, err := f.fetcher.Fetch(context.Background(), srv.URL+tt.path, DefaultBrowserOptions())
+				var redirectErr *RedirectError
+				if !errors.As(err, &redirectErr) {
+					t.Fatalf("Fetch() error = %v, want a RedirectError", err)
+				}
+				if redirectErr.Loop != tt.wantLoop || len(redirectErr.Hops) != tt.wantHops {
+					t.Errorf("loop = %v with %d hops, want %v with %d", redirectErr.Loop, len(redirectErr.Hops), tt.wantLoop, tt.wantHops)
+				}
+				if tt.wantLoop && ClassifyError(err) != models.ErrorRedirectLoop {
+					t.Errorf("category = %s, want %s", ClassifyError(err), models.ErrorRedirectLoop)
+				}
+			})
+		}
+	}
+}
+
+func TestRedirectChainsAreReported(t *testing.T) {
+	srv := redirectServer(t)
+	root := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, linkPage(srv.URL+"/chain/1", srv.URL+"/chain/3", srv.URL+"/loop/a"))
+	}))
+	defer root.Close()
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	opts := testOptions()
+	opts.MaxRedirects = 2
+	result := c.CheckLinksWithOptions(context.Background(), root.URL+"/", 1, opts)
+
+	links := map[string]models.LinkStatus{}
+	for _, l := range result.Links {
+		links[strings.TrimPrefix(l.URL, srv.URL)] = l
+	}
+	if l := links["/chain/1"]; !l.IsWorking || l.FinalURL != srv.URL+"/chain/0" || !slices.Equal(l.RedirectFlags, []string{RedirectPermanent}) {
+		t.Errorf("/chain/1 = working %v, final %s, flags %v", l.IsWorking, l.FinalURL, l.RedirectFlags)
+	}
+	if l := links["/chain/3"]; len(l.Redirects) != 3 || !slices.Equal(l.RedirectFlags, []string{RedirectPermanent, RedirectLongChain}) {
+		t.Errorf("/chain/3 = %d hops, flags %v", len(l.Redirects), l.RedirectFlags)
+	}
+	if l := links["/loop/a"]; l.IsWorking || l.ErrorCategory != models.ErrorRedirectLoop || len(l.Redirects) != 2 {
+		t.Errorf("/loop/a = working %v, category %s, %d hops", l.IsWorking, l.ErrorCategory, len(l.Redirects))
+	}
+}
@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// Resource types reported for every checked URL
+const (
+	ResourcePage       = "page"       // <a href>; the only type that is recursed into
+	ResourceImage      = "image"      // <img>, <picture><source> and srcset candidates
+	ResourceScript     = "script"     // <script src>
+	ResourceStylesheet = "stylesheet" // <link rel=stylesheet>
+	ResourceIframe     = "iframe"     // <iframe src>
+	ResourceMedia      = "media"      // <video>, <audio>, <source src>, <track> and posters
+	ResourceAll        = "all"
+)
+
+// subResourceTypes are the resource types selectable besides pages
+var subResourceTypes = []string{ResourceImage, ResourceScript, ResourceStylesheet, ResourceIframe, ResourceMedia}
+
+// Resource is a URL referenced by a page together with what kind of element referenced it
+type Resource struct {
+	URL    string
+	Type   string
+	Source models.LinkSource
+}
+
+// ResourceTypes validates the sub-resource types to check; "all" selects every type
+func ResourceTypes(names []string) ([]string, error) {
+	var types []string
+	for _, name := range names {
+		switch {
+		case name == ResourceAll:
+			return slices.Clone(subResourceTypes), nil
+		case slices.Contains(subResourceTypes, name):
+			if !slices.Contains(types, name) {
+				types = append(types, name)
+			}
+		default:
+			return nil, fmt.Errorf("unknown resource type %q", name)
+		}
+	}
+	return types, nil
+}
+
+// parseSrcset returns the URLs of the candidates in a srcset attribute
+func parseSrcset(srcset string) []string {
+	var urls []string
+	s := srcset
+	for {
+		s = strings.TrimLeftFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+		if s == "" {
+			return urls
+		}
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			end = len(s)
+		}
+		candidate := s[:end]
+		s = s[end:]
+
+		// A URL directly followed by a comma has no descriptors
+		if trimmed := strings.TrimRight(candidate, ","); trimmed != candidate {
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, candidate)
+
+		// Skip the descriptors up to the next candidate
+		if i := strings.IndexByte(s, ','); i >= 0 {
+			s = s[i+1:]
+		} else {
+			s = ""
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset string
+		want   []string
+	}{
+		{"", nil},
+		{"a.jpg", []string{"a.jpg"}},
+		{"a.jpg 1x, b.jpg 2x", []string{"a.jpg", "b.jpg"}},
+		{"a.jpg 480w,b.jpg 800w", []string{"a.jpg", "b.jpg"}},
+		{"a.jpg, b.jpg", []string{"a.jpg", "b.jpg"}},
+		{"  a.jpg  ,  b.jpg 2x  ", []string{"a.jpg", "b.jpg"}},
+		// Commas inside a URL are part of it; only trailing ones end it
+		{"/img?w=1,2 1x, /img?w=3 2x", []string{"/img?w=1,2", "/img?w=3"}},
+		{"data:image/png;base64,AAAA 1x, b.jpg 2x", []string{"data:image/png;base64,AAAA", "b.jpg"}},
+		{"a.jpg,, b.jpg", []string{"a.jpg", "b.jpg"}},
+	}
+	for _, tt := range tests {
+		if got := parseSrcset(tt.srcset); !slices.Equal(got, tt.want) {
+			t.Errorf("parseSrcset(%q) = %q, want %q", tt.srcset, got, tt.want)
+		}
+	}
+}
+
+func TestResourceTypes(t *testing.T) {
+	tests := []struct {
+		names   []string
+		want    []string
+		wantErr bool
+	}{
+		{nil, nil, false},
+		{[]string{"image", "script", "image"}, []string{"image", "script"}, false},
+		{[]string{"image", "all"}, subResourceTypes, false},
+		{[]string{"font"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ResourceTypes(tt.names)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResourceTypes(%v) error = %v, want error %v", tt.names, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ResourceTypes(%v) = %v, want %v", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestExtractResources(t *testing.T) {
+	const body = `<html><head>
+<base href="https://cdn.example.com/assets/">
+<link rel="stylesheet" href="site.css">
+<link rel="alternate stylesheet" href="dark.css">
+<link rel="icon" href="favicon.ico">
+<script src="app.js"></script>
+<script>inline()</script>
+</head><body>
+<img src="logo.png" srcset="logo-2x.png 2x, logo-3x.png 3x" alt="Logo">
+<img src="data:image/gif;base64,R0lGOD">
+<picture><source srcset="hero.webp 1x, hero-2x.webp 2x"><img src="hero.jpg"></picture>
+<video src="intro.mp4" poster="intro.jpg"><source src="intro.webm"><track src="intro.vtt"></video>
+<audio src="theme.mp3"></audio>
+<iframe src="https://maps.example.com/embed"></iframe>
+</body></html>`
+
+	page := &Page{}
+	extractLinks(strings.NewReader(body), "https://example.com/page", page)
+
+	var got []string
+	for _, r := range page.Resources {
+		got = append(got, r.Type+" "+strings.TrimPrefix(r.URL, "https://cdn.example.com/assets/"))
+	}
+	want := []string{
+		"stylesheet site.css",
+		"stylesheet dark.css",
+		"script app.js",
+		"image logo.png",
+		"image logo-2x.png",
+		"image logo-3x.png",
+		"image hero.webp",
+		"image hero-2x.webp",
+		"image hero.jpg",
+		"media intro.mp4",
+		"media intro.jpg",
+		"media intro.webm",
+		"media intro.vtt",
+		"media theme.mp3",
+		"iframe https://maps.example.com/embed",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("resources =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, r := range page.Resources {
+		if strings.HasPrefix(r.URL, "https://cdn.example.com/assets/logo") && r.Source.Text != "Logo" {
+			t.Errorf("%s has text %q, want the alt text", r.URL, r.Source.Text)
+		}
+	}
+}
+
+func TestResourcesAreCheckedNotCrawled(t *testing.T) {
+	srv := serveSite(t, map[string]string{
+		"/": `<html><body>
+<img src="/ok.png"><img src="/missing.png">
+<script src="/app.js"></script>
+<iframe src="/frame"></iframe>
+</body></html>`,
+		"/ok.png": "png",
+		"/frame":  linkPage("/from-frame"),
+	})
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	opts := testOptions()
+	opts.Resources = []string{ResourceImage, ResourceIframe}
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 3, opts)
+
+	got := map[string]string{}
+	for _, l := range result.Links {
+		got[strings.TrimPrefix(l.URL, srv.URL)] = fmt.Sprintf("%s %d", l.ResourceType, l.StatusCode)
+	}
+	want := map[string]string{
+		"/":            "page 200",
+		"/ok.png":      "image 200",
+		"/missing.png": "image 404",
+		"/frame":       "iframe 200",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("checked %v, want %v", got, want)
+	}
+}
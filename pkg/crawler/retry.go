@@ -0,0 +1,142 @@
+package crawler
+
+import (
+	"errors"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// RetryPolicy decides which failed fetches are retried and how long to wait in between
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts per URL, including the first one
+	BaseDelay   time.Duration // Delay before the first retry, doubled for every further one
+	MaxDelay    time.Duration // Upper bound for a single delay, including Retry-After
+	// Jitter randomizes each delay by up to this fraction in either
+	// direction so retries against one host don't line up
+	Jitter float64
+	// RetryStatuses are the response status codes worth retrying
+	RetryStatuses []int
+}
+
+// DefaultRetryPolicy returns the default retry policy
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+		RetryStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// RetryableError reports whether a fetch error may go away on its own:
+// timeouts, refused or reset connections, other network failures and
+// temporary DNS failures. Anything else fails the same way every time.
+func (p RetryPolicy) RetryableError(err error) bool {
+	switch ClassifyError(err) {
+	case models.ErrorTimeout, models.ErrorConnectionRefused, models.ErrorConnectionReset, models.ErrorNetwork:
+		return true
+	case models.ErrorDNS:
+		var dnsErr *net.DNSError
+		return errors.As(err, &dnsErr) && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+	}
+	return false
+}
+
+// RetryableStatus reports whether a response status is worth retrying
+func (p RetryPolicy) RetryableStatus(code int) bool {
+	return slices.Contains(p.RetryStatuses, code)
+}
+
+// Backoff returns the delay after the given failed attempt, counting from 1.
+// A longer Retry-After requested by the server wins, up to MaxDelay.
+func (p RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	d := time.Duration(delay)
+	if retryAfter > d {
+		d = retryAfter
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// fetch loads a URL, retrying failures the retry policy allows. Every
+// attempt waits for its own scheduler slot, so the slot is free during the
+// backoff and a pause the host asked for delays the retry. It returns the
+// total time spent waiting for slots; the attempt history is only
+// returned when the URL was retried.
+func (s *session) fetch(fetcher Fetcher, url string, crawlDelay time.Duration) (*Page, []models.Attempt, time.Duration, error) {
+	policy := s.opts.Retry
+	var history []models.Attempt
+	var queueTime time.Duration
+	var page *Page
+	var err error
+	for attempt := 1; ; attempt++ {
+		release, waited, acquireErr := s.acquire(url, crawlDelay)
+		if acquireErr != nil {
+			// Stopped while waiting: keep the previous attempt, if any
+			if attempt == 1 {
+				err = acquireErr
+			}
+			return page, history, queueTime, err
+		}
+		queueTime += waited
+
+		start := time.Now()
+		page, err = fetcher.Fetch(s.ctx, url, s.opts.Browser)
+
+		record := models.Attempt{Duration: time.Since(start).String()}
+		var retryAfter time.Duration
+		retry := false
+		if err != nil {
+			record.Error = err.Error()
+			record.ErrorCategory = ClassifyError(err)
+			retry = policy.RetryableError(err)
+		} else {
+			s.sched.report(url, page.StatusCode, page.RetryAfter)
+			record.StatusCode = page.StatusCode
+			retryAfter = page.RetryAfter
+			retry = policy.RetryableStatus(page.StatusCode)
+		}
+		release()
+
+		if !retry || attempt >= policy.MaxAttempts || s.ctx.Err() != nil {
+			if len(history) > 0 {
+				history = append(history, record)
+			}
+			return page, history, queueTime, err
+		}
+
+		delay := policy.Backoff(attempt, retryAfter)
+		record.Delay = delay.String()
+		history = append(history, record)
+		if err != nil {
+			log.Printf("Attempt %d failed for %s: %v, retrying in %s\n", attempt, url, err, delay)
+		} else {
+			log.Printf("Attempt %d for %s returned %d, retrying in %s\n", attempt, url, page.StatusCode, delay)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return page, history, queueTime, err
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"timeout", fmt.Errorf("get: %w", context.DeadlineExceeded), true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"temporary dns", &net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}, true},
+		{"dns timeout", &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}, true},
+		{"unknown host", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, false},
+		{"tls", x509.UnknownAuthorityError{}, false},
+		{"redirect loop", &RedirectError{Loop: true}, false},
+		{"unknown", errors.New("unsupported protocol scheme"), false},
+	}
+	policy := DefaultRetryPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.RetryableError(tt.err); got != tt.want {
+				t.Errorf("RetryableError(%v) = %v, want %v (category %s)", tt.err, got, tt.want, ClassifyError(tt.err))
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	tests := []struct {
+		attempt    int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{1, 0, time.Second},
+		{2, 0, 2 * time.Second},
+		{3, 0, 4 * time.Second},
+		{4, 0, 5 * time.Second},
+		{1, 3 * time.Second, 3 * time.Second},
+		{1, time.Minute, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := policy.Backoff(tt.attempt, tt.retryAfter); got != tt.want {
+			t.Errorf("Backoff(%d, %s) = %s, want %s", tt.attempt, tt.retryAfter, got, tt.want)
+		}
+	}
+}
+
+// requestLog records the order and time of the requests to a test server
+type requestLog struct {
+	mu       sync.Mutex
+	paths    []string
+	times    []time.Time
+	attempts map[string]int
+}
+
+func (l *requestLog) add(path string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.paths = append(l.paths, path)
+	l.times = append(l.times, time.Now())
+	if l.attempts == nil {
+		l.attempts = make(map[string]int)
+	}
+	l.attempts[path]++
+	return l.attempts[path]
+}
+
+// retryOptions fetch everything with GET, one request at a time
+func retryOptions() CrawlOptions {
+	opts := testOptions()
+	opts.HeadFirst = false
+	opts.Browser.MaxConcurrent = 1
+	opts.Retry = RetryPolicy{MaxAttempts: 2, RetryStatuses: []int{http.StatusInternalServerError, http.StatusServiceUnavailable}}
+	return opts
+}
+
+func TestRetryWaitsForHostPause(t *testing.T) {
+	var log requestLog
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if log.add(r.URL.Path) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	opts := retryOptions()
+	// The retry delay is capped far below the pause the host asked for
+	opts.Retry.BaseDelay = 10 * time.Millisecond
+	opts.Retry.MaxDelay = 10 * time.Millisecond
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 1, opts)
+
+	if len(result.Links) != 1 || result.Links[0].StatusCode != http.StatusOK || len(result.Links[0].Attempts) != 2 {
+		t.Fatalf("unexpected result %+v", result.Links)
+	}
+	if gap := log.times[1].Sub(log.times[0]); gap < 900*time.Millisecond {
+		t.Errorf("retried after %s, want the 1s pause from Retry-After", gap)
+	}
+}
+
+func TestRetryBackoffFreesSlot(t *testing.T) {
+	var log requestLog
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/":
+			fmt.Fprint(w, linkPage("/other", "/flaky"))
+			return
+		}
+		if log.add(r.URL.Path) == 1 && r.URL.Path == "/flaky" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	opts := retryOptions()
+	opts.Retry.BaseDelay = 300 * time.Millisecond
+	c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 2, opts)
+
+	// With a single slot, /other only gets in before the retry when the
+	// slot is released during the backoff. /flaky is linked last, so it
+	// usually starts first.
+	if got := fmt.Sprint(log.paths); got != "[/flaky /other /flaky]" && got != "[/other /flaky /flaky]" {
+		t.Errorf("requests = %v, want /other before the retry of /flaky", got)
+	}
+	if log.attempts["/flaky"] != 2 {
+		t.Errorf("/flaky requested %d times, want 2", log.attempts["/flaky"])
+	}
+}
@@ -0,0 +1,273 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RobotsUserAgent is the product token matched against robots.txt groups
+const RobotsUserAgent = "BrokenLinksTester"
+
+// UserAgent is sent with every request, so sites see the same agent the
+// crawler picks robots.txt rules for
+const UserAgent = "Mozilla/5.0 (compatible; " + RobotsUserAgent + "/1.0)"
+
+// maxRobotsSize caps how much of a robots.txt file is read
+const maxRobotsSize = 512 << 10
+
+// robotsServerErrorTTL is how long a robots.txt server error disallows its
+// origin before the file is requested again
+const robotsServerErrorTTL = time.Minute
+
+// robotsRules are the rules of the robots.txt group that applies to us
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// allowed reports whether the path (including any query) may be crawled.
+// The longest matching pattern wins and Allow wins ties.
+func (r *robotsRules) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	best := -1
+	allow := true
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best = n
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// parseRobots parses a robots.txt file and returns the rules of the group
+// matching agent, falling back to the "*" group
+func parseRobots(body io.Reader, agent string) *robotsRules {
+	agent = strings.ToLower(agent)
+
+	type group struct {
+		agents []string
+		rules  robotsRules
+	}
+	var groups []*group
+	var current *group
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share one group
+			if current == nil || !lastWasAgent {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			// An empty Disallow allows everything and adds no rule
+			if current != nil && value != "" {
+				current.rules.rules = append(current.rules.rules, robotsRule{
+					allow:   key == "allow",
+					pattern: value,
+					re:      robotsPattern(value),
+				})
+			}
+		case "crawl-delay":
+			if current != nil {
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+					current.rules.crawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		}
+		lastWasAgent = false
+	}
+
+	// Prefer the most specific group naming our agent over the wildcard group
+	var match *robotsRules
+	matchLen := -1
+	for _, g := range groups {
+		for _, a := range g.agents {
+			switch {
+			case a == "*" && matchLen < 0:
+				match, matchLen = &g.rules, 0
+			case a != "*" && strings.Contains(agent, a) && len(a) > matchLen:
+				match, matchLen = &g.rules, len(a)
+			}
+		}
+	}
+	return match
+}
+
+// robotsPattern turns a robots.txt path pattern with * and $ into a regexp
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i, part := range strings.Split(pattern, "*") {
+		if i > 0 {
+			b.WriteString(".*")
+		}
+		b.WriteString(regexp.QuoteMeta(part))
+	}
+	if anchored {
+		b.WriteString("$")
+	}
+	return regexp.MustCompile(b.String())
+}
+
+// robotsCache fetches robots.txt once per origin, or again once the
+// disallow-all stand-in for a server error expires
+type robotsCache struct {
+	client         *http.Client
+	timeout        time.Duration
+	serverErrorTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	mu      sync.Mutex
+	fetched bool
+	rules   *robotsRules
+	expires time.Time // Set when rules stand in for a server error
+}
+
+func newRobotsCache(timeout time.Duration) *robotsCache {
+	return &robotsCache{
+		client:         &http.Client{},
+		timeout:        timeout,
+		serverErrorTTL: robotsServerErrorTTL,
+		entries:        make(map[string]*robotsEntry),
+	}
+}
+
+// rules returns the robots.txt rules for the origin of u, fetching them on
+// first use and after a server error expires
+func (c *robotsCache) rules(ctx context.Context, u *url.URL) *robotsRules {
+	origin := u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+	entry, ok := c.entries[origin]
+	if !ok {
+		entry = &robotsEntry{}
+		c.entries[origin] = entry
+	}
+	c.mu.Unlock()
+
+	// Holding the entry lock makes concurrent lookups wait for one fetch
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if !entry.fetched || (!entry.expires.IsZero() && time.Now().After(entry.expires)) {
+		var serverError bool
+		entry.rules, serverError = c.fetch(ctx, origin)
+		entry.fetched = true
+		entry.expires = time.Time{}
+		if serverError {
+			entry.expires = time.Now().Add(c.serverErrorTTL)
+		}
+	}
+	return entry.rules
+}
+
+// Allowed reports whether robots.txt permits crawling rawURL
+func (c *robotsCache) Allowed(ctx context.Context, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return true
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return c.rules(ctx, u).allowed(path)
+}
+
+// CrawlDelay returns the Crawl-delay robots.txt asks for on rawURL's origin
+func (c *robotsCache) CrawlDelay(ctx context.Context, rawURL string) time.Duration {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return 0
+	}
+	if rules := c.rules(ctx, u); rules != nil {
+		return rules.crawlDelay
+	}
+	return 0
+}
+
+// fetch requests the robots.txt of origin. serverError is set when the
+// rules only stand in for a file the server failed to return.
+func (c *robotsCache) fetch(ctx context.Context, origin string) (rules *robotsRules, serverError bool) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", origin+"/robots.txt", nil)
+	if err != nil {
+		return nil, false
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Unlike a server error, an unreachable origin is left to the link
+		// checks, which report why it can't be reached
+		log.Printf("Error fetching robots.txt for %s: %v\n", origin, err)
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		// A server error may hide rules, so nothing may be crawled (RFC 9309)
+		// until robots.txt is requested again
+		log.Printf("robots.txt for %s answered %d, disallowing the origin for %v\n", origin, resp.StatusCode, c.serverErrorTTL)
+		return disallowAll(), true
+	case resp.StatusCode != http.StatusOK:
+		// A missing or forbidden robots.txt allows everything
+		return nil, false
+	}
+	return parseRobots(io.LimitReader(resp.Body, maxRobotsSize), RobotsUserAgent), false
+}
+
+// disallowAll returns rules that disallow every path
+func disallowAll() *robotsRules {
+	return &robotsRules{rules: []robotsRule{{pattern: "/", re: robotsPattern("/")}}}
+}
@@ -0,0 +1,250 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRobotsRules(t *testing.T) {
+	const robots = `
+User-agent: *
+Disallow: /
+
+User-agent: BrokenLinksTester
+Disallow: /private/
+Allow: /private/public/
+Disallow: /*.pdf$
+Disallow: /search?*q=
+Allow: /tie
+Disallow: /tie
+Disallow:
+`
+	rules := parseRobots(strings.NewReader(robots), RobotsUserAgent)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/about", true},
+		{"/private/", false},
+		{"/private/data", false},
+		// The longer Allow wins over the shorter Disallow
+		{"/private/public/page", true},
+		// $ anchors the pattern at the end of the path
+		{"/files/report.pdf", false},
+		{"/files/report.pdf?download=1", true},
+		{"/files/report.pdfx", true},
+		// * matches any characters, including in the query
+		{"/search?lang=en&q=links", false},
+		{"/search?lang=en", true},
+		// Allow wins ties between patterns of equal length
+		{"/tie", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRobotsGroupSelection(t *testing.T) {
+	tests := []struct {
+		name   string
+		robots string
+		path   string
+		want   bool
+	}{
+		{"wildcard group", "User-agent: *\nDisallow: /a", "/a", false},
+		{"own group wins over wildcard", "User-agent: *\nDisallow: /a\n\nUser-agent: brokenlinkstester\nDisallow: /b", "/a", true},
+		{"other agent ignored", "User-agent: OtherBot\nDisallow: /", "/a", true},
+		{"shared group", "User-agent: OtherBot\nUser-agent: BrokenLinksTester\nDisallow: /a", "/a", false},
+		{"comments ignored", "User-agent: * # everyone\nDisallow: /a # not /b", "/b", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(tt.robots), RobotsUserAgent)
+			if got := rules.allowed(tt.path); got != tt.want {
+				t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotsStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   bool
+	}{
+		{"rules", http.StatusOK, "User-agent: *\nDisallow: /page", false},
+		{"not found allows all", http.StatusNotFound, "", true},
+		{"forbidden allows all", http.StatusForbidden, "", true},
+		{"server error disallows all", http.StatusServiceUnavailable, "", false},
+		{"internal error disallows all", http.StatusInternalServerError, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/robots.txt" {
+					t.Errorf("unexpected request for %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			cache := newRobotsCache(time.Second)
+			if got := cache.Allowed(context.Background(), srv.URL+"/page"); got != tt.want {
+				t.Errorf("Allowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotsServerErrorExpires(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		first := requests == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private")
+	}))
+	defer srv.Close()
+
+	const ttl = 100 * time.Millisecond
+	cache := newRobotsCache(time.Second)
+	cache.serverErrorTTL = ttl
+	ctx := context.Background()
+
+	if cache.Allowed(ctx, srv.URL+"/page") || cache.Allowed(ctx, srv.URL+"/page") {
+		t.Fatal("Allowed() = true while robots.txt answers a server error")
+	}
+	mu.Lock()
+	if requests != 1 {
+		t.Errorf("robots.txt requested %d times before the error expired, want 1", requests)
+	}
+	mu.Unlock()
+
+	time.Sleep(2 * ttl)
+	if !cache.Allowed(ctx, srv.URL+"/page") {
+		t.Error("Allowed(/page) = false after robots.txt recovered")
+	}
+	if cache.Allowed(ctx, srv.URL+"/private") {
+		t.Error("Allowed(/private) = true after robots.txt recovered")
+	}
+	mu.Lock()
+	if requests != 2 {
+		t.Errorf("robots.txt requested %d times, want 2", requests)
+	}
+	mu.Unlock()
+}
+
+func TestUserAgentMatchesRobotsToken(t *testing.T) {
+	if !strings.Contains(UserAgent, RobotsUserAgent) {
+		t.Fatalf("UserAgent %q does not contain %q", UserAgent, RobotsUserAgent)
+	}
+
+	var mu sync.Mutex
+	agents := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents[r.Method+" "+r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, linkPage())
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	newRobotsCache(time.Second).Allowed(ctx, srv.URL+"/")
+	if _, err := NewHTTPFetcher().Fetch(ctx, srv.URL+"/page", DefaultBrowserOptions()); err != nil {
+		t.Fatalf("HTTPFetcher.Fetch() error = %v", err)
+	}
+	if _, err := NewHeadFetcher().Fetch(ctx, srv.URL+"/head", DefaultBrowserOptions()); err != nil {
+		t.Fatalf("HeadFetcher.Fetch() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, req := range []string{"GET /robots.txt", "GET /page", "HEAD /head"} {
+		if got := agents[req]; got != UserAgent {
+			t.Errorf("%s sent User-Agent %q, want %q", req, got, UserAgent)
+		}
+	}
+}
+
+func TestRobotsDisallowedLinksAreSkipped(t *testing.T) {
+	srv := serveSite(t, map[string]string{
+		"/":           linkPage("/public", "/private/page"),
+		"/public":     linkPage(),
+		"/robots.txt": "User-agent: *\nDisallow: /private/",
+	})
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 2, testOptions())
+
+	for _, l := range result.Links {
+		private := strings.HasSuffix(l.URL, "/private/page")
+		if l.Skipped != private {
+			t.Errorf("%s: skipped = %v, want %v", l.URL, l.Skipped, private)
+		}
+	}
+}
+
+func TestRobotsCrawlDelay(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	var mu sync.Mutex
+	var requests []time.Time
+	pages := map[string]string{
+		"/":  linkPage("/a", "/b", "/c"),
+		"/a": linkPage(),
+		"/b": linkPage(),
+		"/c": linkPage(),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nCrawl-delay: 0.2")
+			return
+		}
+		mu.Lock()
+		requests = append(requests, time.Now())
+		mu.Unlock()
+		fmt.Fprint(w, pages[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	opts := testOptions()
+	opts.Browser.MaxConcurrent = 4
+	c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 2, opts)
+
+	if len(requests) != len(pages) {
+		t.Fatalf("got %d requests, want %d", len(requests), len(pages))
+	}
+	// Allow for timer slack, but requests must not arrive back to back
+	for i := 1; i < len(requests); i++ {
+		if gap := requests[i].Sub(requests[i-1]); gap < delay*9/10 {
+			t.Errorf("request %d came %s after the previous one, want at least %s", i, gap, delay)
+		}
+	}
+}
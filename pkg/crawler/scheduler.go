@@ -0,0 +1,200 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// initialBackoff is the first pause after a host answers 429/503 without Retry-After
+const initialBackoff = time.Second
+
+// PolitenessOptions limits how hard a single host is hit
+type PolitenessOptions struct {
+	MaxPerHost        int     // Concurrent requests per host; 0 means only the global cap applies
+	RequestsPerSecond float64 // Sustained request rate per host; 0 means unlimited
+	Burst             int     // Requests a host may receive at once before the rate applies
+	MaxBackoff        time.Duration
+}
+
+// DefaultPolitenessOptions returns default politeness options
+func DefaultPolitenessOptions() PolitenessOptions {
+	return PolitenessOptions{
+		MaxPerHost:        4,
+		RequestsPerSecond: 10,
+		Burst:             10,
+		MaxBackoff:        time.Minute,
+	}
+}
+
+// scheduler hands out request slots between link discovery and fetching.
+// Each host gets its own concurrency cap, token bucket and backoff state,
+// so a slow or throttling host does not hold every global slot.
+type scheduler struct {
+	opts   PolitenessOptions
+	global chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	slots chan struct{} // nil when there is no per-host cap
+
+	mu          sync.Mutex
+	tat         time.Time // theoretical arrival time of the token bucket
+	pausedUntil time.Time
+	backoff     time.Duration
+}
+
+func newScheduler(maxConcurrent int, opts PolitenessOptions) *scheduler {
+	return &scheduler{
+		opts:   opts,
+		global: make(chan struct{}, max(maxConcurrent, 1)),
+		hosts:  make(map[string]*hostState),
+	}
+}
+
+func (s *scheduler) host(name string) *hostState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.hosts[name]
+	if !ok {
+		h = &hostState{}
+		if s.opts.MaxPerHost > 0 {
+			h.slots = make(chan struct{}, s.opts.MaxPerHost)
+		}
+		s.hosts[name] = h
+	}
+	return h
+}
+
+// acquire waits for a host slot, the host's rate limit and backoff, and
+// then a global slot. crawlDelay is the host's robots.txt Crawl-delay.
+// The returned release function must be called once the request is done.
+func (s *scheduler) acquire(ctx context.Context, rawURL string, crawlDelay time.Duration) (func(), error) {
+	h := s.host(hostKey(rawURL))
+
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	releaseHost := func() {
+		if h.slots != nil {
+			<-h.slots
+		}
+	}
+
+	if wait := time.Until(h.reserve(s.opts, crawlDelay)); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			releaseHost()
+			return nil, ctx.Err()
+		}
+	}
+
+	select {
+	case s.global <- struct{}{}:
+	case <-ctx.Done():
+		releaseHost()
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-s.global
+		releaseHost()
+	}, nil
+}
+
+// reserve books the next start time allowed by the token bucket, the
+// crawl delay and any backoff pause
+func (h *hostState) reserve(opts PolitenessOptions, crawlDelay time.Duration) time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if h.pausedUntil.After(now) {
+		now = h.pausedUntil
+	}
+
+	// The token bucket is tracked as a GCRA: one request per interval,
+	// with up to burst requests allowed ahead of schedule
+	var interval, tolerance time.Duration
+	if opts.RequestsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / opts.RequestsPerSecond)
+		tolerance = time.Duration(max(opts.Burst-1, 0)) * interval
+	}
+	if crawlDelay > interval {
+		interval, tolerance = crawlDelay, 0
+	}
+	if interval == 0 {
+		return now
+	}
+
+	if h.tat.Before(now) {
+		h.tat = now
+	}
+	start := h.tat.Add(-tolerance)
+	if start.Before(now) {
+		start = now
+	}
+	h.tat = h.tat.Add(interval)
+	return start
+}
+
+// report adapts the host's backoff to a response: 429 and 503 pause the
+// host for Retry-After or an exponentially growing delay, anything else resets it
+func (s *scheduler) report(rawURL string, statusCode int, retryAfter time.Duration) {
+	h := s.host(hostKey(rawURL))
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if statusCode != http.StatusTooManyRequests && statusCode != http.StatusServiceUnavailable {
+		h.backoff = 0
+		return
+	}
+
+	switch {
+	case retryAfter > 0:
+		h.backoff = retryAfter
+	case h.backoff == 0:
+		h.backoff = initialBackoff
+	default:
+		h.backoff *= 2
+	}
+	if s.opts.MaxBackoff > 0 && h.backoff > s.opts.MaxBackoff {
+		h.backoff = s.opts.MaxBackoff
+	}
+	h.pausedUntil = time.Now().Add(h.backoff)
+}
+
+func hostKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func ParseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
@@ -0,0 +1,187 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// tryAcquire attempts to get a slot within wait
+func tryAcquire(s *scheduler, rawURL string, wait time.Duration) (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+	return s.acquire(ctx, rawURL, 0)
+}
+
+func TestSchedulerMaxPerHost(t *testing.T) {
+	s := newScheduler(10, PolitenessOptions{MaxPerHost: 2})
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, err := tryAcquire(s, "https://a.example/", 50*time.Millisecond)
+		if err != nil {
+			t.Fatalf("slot %d of a.example: %v", i, err)
+		}
+		releases = append(releases, release)
+	}
+	if _, err := tryAcquire(s, "https://a.example/third", 50*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("third slot of a.example error = %v, want it to wait", err)
+	}
+
+	// A busy host does not hold up others
+	release, err := tryAcquire(s, "https://b.example/", 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("slot of b.example: %v", err)
+	}
+	release()
+
+	releases[0]()
+	release, err = tryAcquire(s, "https://a.example/third", 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("slot of a.example after a release: %v", err)
+	}
+	release()
+	releases[1]()
+}
+
+func TestSchedulerGlobalCap(t *testing.T) {
+	s := newScheduler(1, PolitenessOptions{})
+
+	release, err := tryAcquire(s, "https://a.example/", 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tryAcquire(s, "https://b.example/", 50*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second global slot error = %v, want it to wait", err)
+	}
+	release()
+	if release, err = tryAcquire(s, "https://b.example/", 50*time.Millisecond); err != nil {
+		t.Fatalf("global slot after a release: %v", err)
+	}
+	release()
+}
+
+func TestReserveSpacing(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	tests := []struct {
+		name       string
+		opts       PolitenessOptions
+		crawlDelay time.Duration
+		want       []time.Duration // Start of each request after the first
+	}{
+		{"unlimited", PolitenessOptions{}, 0, []time.Duration{0, 0, 0, 0}},
+		{"no burst", PolitenessOptions{RequestsPerSecond: 10, Burst: 1}, 0, []time.Duration{0, interval, 2 * interval, 3 * interval}},
+		{"burst", PolitenessOptions{RequestsPerSecond: 10, Burst: 3}, 0, []time.Duration{0, 0, 0, interval}},
+		{"crawl delay", PolitenessOptions{RequestsPerSecond: 100, Burst: 10}, interval, []time.Duration{0, interval, 2 * interval, 3 * interval}},
+		{"shorter crawl delay", PolitenessOptions{RequestsPerSecond: 10, Burst: 1}, time.Millisecond, []time.Duration{0, interval, 2 * interval, 3 * interval}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h hostState
+			now := time.Now()
+			for i, want := range tt.want {
+				got := h.reserve(tt.opts, tt.crawlDelay).Sub(now)
+				if d := got - want; d < 0 || d > 20*time.Millisecond {
+					t.Errorf("request %d starts after %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerRateLimitsAcquire(t *testing.T) {
+	s := newScheduler(10, PolitenessOptions{RequestsPerSecond: 20, Burst: 1})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := tryAcquire(s, "https://a.example/", time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests at 20/s took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestSchedulerBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		retryAfter time.Duration
+		maxBackoff time.Duration
+		want       time.Duration
+	}{
+		{"retry after", []int{http.StatusTooManyRequests}, 3 * time.Second, time.Minute, 3 * time.Second},
+		{"first pause", []int{http.StatusServiceUnavailable}, 0, time.Minute, initialBackoff},
+		{"doubles", []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusServiceUnavailable}, 0, time.Minute, 4 * initialBackoff},
+		{"capped", []int{http.StatusTooManyRequests}, time.Hour, time.Minute, time.Minute},
+		{"reset by success", []int{http.StatusTooManyRequests, http.StatusOK}, 0, time.Minute, 0},
+		{"other errors", []int{http.StatusInternalServerError}, 0, time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScheduler(1, PolitenessOptions{MaxBackoff: tt.maxBackoff})
+			for _, code := range tt.statuses {
+				s.report("https://a.example/", code, tt.retryAfter)
+			}
+			h := s.host("a.example")
+			if h.backoff != tt.want {
+				t.Errorf("backoff = %v, want %v", h.backoff, tt.want)
+			}
+			if s.host("b.example").backoff != 0 {
+				t.Error("backoff leaked to another host")
+			}
+		})
+	}
+}
+
+func TestSchedulerPausesAfterRetryAfter(t *testing.T) {
+	s := newScheduler(10, PolitenessOptions{})
+	s.report("https://a.example/", http.StatusTooManyRequests, 200*time.Millisecond)
+
+	start := time.Now()
+	release, err := tryAcquire(s, "https://a.example/next", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("request after Retry-After 200ms started after %v", elapsed)
+	}
+
+	// The paused host does not delay others
+	s.report("https://a.example/", http.StatusTooManyRequests, time.Minute)
+	release, err = tryAcquire(s, "https://b.example/", 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("b.example waited on a.example's pause: %v", err)
+	}
+	release()
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{"0", 0},
+		{"-3", 0},
+		{"soon", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := ParseRetryAfter(future); got < 59*time.Minute || got > time.Hour {
+		t.Errorf("ParseRetryAfter(%q) = %v, want about an hour", future, got)
+	}
+}
@@ -0,0 +1,174 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Scope modes deciding which pages have their links followed
+const (
+	ScopeAll    = "all"    // Follow links on every page
+	ScopeHost   = "host"   // Only expand pages on the start URL's host
+	ScopeDomain = "domain" // Only expand pages on the start URL's registrable domain
+	ScopePrefix = "prefix" // Only expand pages under a URL prefix
+)
+
+// Scope limits recursion to a part of the web. Links pointing outside
+// the scope are still checked, but the pages they lead to are not expanded.
+type Scope struct {
+	Mode string
+	// Prefix is the URL prefix for ScopePrefix, matched on whole path
+	// segments; it defaults to the start URL after redirects
+	Prefix string
+}
+
+// scopeMatcher is a Scope bound to the start URL of a crawl
+type scopeMatcher struct {
+	scope  Scope
+	host   string // host[:port] of the start URL, without a default port
+	domain string
+	// prefix is the normalized prefix URL, whose path is matched on whole segments
+	prefix *url.URL
+}
+
+// newScopeMatcher binds scope to baseURL, which should be the start page
+// after redirects. The prefix is normalized like the URLs it is matched against.
+func newScopeMatcher(scope Scope, baseURL string, normalize NormalizeOptions) *scopeMatcher {
+	m := &scopeMatcher{scope: scope}
+	if base, err := url.Parse(baseURL); err == nil {
+		m.host = scopeHost(base)
+		m.domain = registrableDomain(strings.ToLower(base.Hostname()))
+	}
+	prefix := scope.Prefix
+	if prefix == "" {
+		prefix = baseURL
+	}
+	if u, err := url.Parse(normalize.Normalize(prefix)); err == nil {
+		m.prefix = u
+	}
+	return m
+}
+
+// contains reports whether links on the page at rawURL should be followed
+func (m *scopeMatcher) contains(rawURL string) bool {
+	switch m.scope.Mode {
+	case ScopeHost:
+		u, err := url.Parse(rawURL)
+		return err == nil && scopeHost(u) == m.host
+	case ScopeDomain:
+		u, err := url.Parse(rawURL)
+		return err == nil && registrableDomain(strings.ToLower(u.Hostname())) == m.domain
+	case ScopePrefix:
+		u, err := url.Parse(rawURL)
+		return err == nil && m.prefix != nil && strings.EqualFold(u.Scheme, m.prefix.Scheme) &&
+			scopeHost(u) == scopeHost(m.prefix) && underPath(u.Path, m.prefix.Path)
+	default:
+		return true
+	}
+}
+
+// scopeHost returns the lowercase host of u with the scheme's default port removed
+func scopeHost(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" || (port == "80" && strings.EqualFold(u.Scheme, "http")) || (port == "443" && strings.EqualFold(u.Scheme, "https")) {
+		return host
+	}
+	return host + ":" + port
+}
+
+// underPath reports whether path is prefix or below it, so /docs
+// contains /docs/api but not /docs-old
+func underPath(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func registrableDomain(host string) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		// IP addresses and bare hosts like localhost are their own domain
+		return host
+	}
+	return domain
+}
+
+// URLFilter decides which discovered URLs are enqueued at all.
+// Patterns starting with "re:" are regular expressions; anything else
+// is a glob where * matches any run of characters and ? a single one.
+type URLFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// NewURLFilter compiles include and exclude pattern lists. A URL is kept
+// when it matches any include pattern (or there are none) and no exclude pattern.
+func NewURLFilter(include, exclude []string) (*URLFilter, error) {
+	f := &URLFilter{}
+	var err error
+	if f.include, err = compilePatterns(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compilePatterns(exclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Allow reports whether the URL passes the filter; a nil filter allows everything
+func (f *URLFilter) Allow(rawURL string) bool {
+	if f == nil {
+		return true
+	}
+	for _, re := range f.exclude {
+		if re.MatchString(rawURL) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		expr := globToRegexp(p)
+		if strings.HasPrefix(p, "re:") {
+			expr = strings.TrimPrefix(p, "re:")
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
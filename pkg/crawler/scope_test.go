@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScopeContains(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope Scope
+		base  string
+		url   string
+		want  bool
+	}{
+		{"all", Scope{Mode: ScopeAll}, "https://example.com/", "https://other.org/", true},
+		{"host", Scope{Mode: ScopeHost}, "https://example.com/", "https://example.com/a", true},
+		{"host is case-insensitive", Scope{Mode: ScopeHost}, "https://Example.COM/", "https://example.com/a", true},
+		{"host ignores default port", Scope{Mode: ScopeHost}, "https://example.com/", "https://example.com:443/a", true},
+		{"host base with default port", Scope{Mode: ScopeHost}, "http://example.com:80/", "http://example.com/a", true},
+		{"host other port", Scope{Mode: ScopeHost}, "https://example.com/", "https://example.com:8443/a", false},
+		{"host subdomain", Scope{Mode: ScopeHost}, "https://example.com/", "https://docs.example.com/a", false},
+		{"domain subdomain", Scope{Mode: ScopeDomain}, "https://www.example.com/", "https://docs.example.com/a", true},
+		{"domain public suffix", Scope{Mode: ScopeDomain}, "https://a.github.io/", "https://b.github.io/", false},
+		{"prefix below", Scope{Mode: ScopePrefix}, "https://example.com/docs", "https://example.com/docs/api", true},
+		{"prefix itself", Scope{Mode: ScopePrefix}, "https://example.com/docs/", "https://example.com/docs", true},
+		{"prefix sibling", Scope{Mode: ScopePrefix}, "https://example.com/docs", "https://example.com/docs-old", false},
+		{"prefix other host", Scope{Mode: ScopePrefix}, "https://example.com/docs", "https://example.org/docs/api", false},
+		{"prefix other scheme", Scope{Mode: ScopePrefix}, "https://example.com/docs", "http://example.com/docs/api", false},
+		{"prefix default port", Scope{Mode: ScopePrefix}, "https://EXAMPLE.com:443/docs", "https://example.com/docs/api", true},
+		{"prefix root", Scope{Mode: ScopePrefix}, "https://example.com", "https://example.com/anything", true},
+		{"explicit prefix", Scope{Mode: ScopePrefix, Prefix: "https://example.com/blog/"}, "https://example.com/", "https://example.com/blog/post", true},
+		{"explicit prefix excludes base", Scope{Mode: ScopePrefix, Prefix: "https://example.com/blog/"}, "https://example.com/", "https://example.com/about", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newScopeMatcher(tt.scope, tt.base, DefaultNormalizeOptions())
+			if got := m.contains(tt.url); got != tt.want {
+				t.Errorf("contains(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeFollowsStartPageRedirect(t *testing.T) {
+	pages := map[string]string{
+		"/docs/":      linkPage("/docs/guide", "/blog/"),
+		"/docs/guide": linkPage("/docs/guide/install"),
+		"/blog/":      linkPage("/blog/post"),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/docs/", http.StatusMovedPermanently)
+			return
+		}
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			page = linkPage()
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, page)
+	}))
+	defer srv.Close()
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	opts := testOptions()
+	opts.Scope = Scope{Mode: ScopePrefix}
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/start", 4, opts)
+
+	checked := map[string]bool{}
+	for _, l := range result.Links {
+		checked[strings.TrimPrefix(l.URL, srv.URL)] = true
+	}
+	// Pages under /docs/ are expanded, /blog/ is checked but not expanded
+	for path, want := range map[string]bool{"/docs/guide": true, "/docs/guide/install": true, "/blog": true, "/blog/post": false} {
+		if checked[path] != want {
+			t.Errorf("%s checked = %v, want %v", path, checked[path], want)
+		}
+	}
+}
+
+func TestURLFilter(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude []string
+		url              string
+		want             bool
+	}{
+		{"no patterns", nil, nil, "https://example.com/a", true},
+		{"include glob", []string{"https://example.com/docs/*"}, nil, "https://example.com/docs/a", true},
+		{"include miss", []string{"https://example.com/docs/*"}, nil, "https://example.com/blog/a", false},
+		{"question mark", []string{"https://example.com/v?/*"}, nil, "https://example.com/v2/a", true},
+		{"glob is anchored", nil, []string{"*.pdf"}, "https://example.com/a.pdf?x=1", true},
+		{"exclude glob", nil, []string{"*.pdf"}, "https://example.com/a.pdf", false},
+		{"glob escapes dots", nil, []string{"*.pdf"}, "https://example.com/apdf", true},
+		{"exclude regexp", nil, []string{"re:/tags?/"}, "https://example.com/tag/go", false},
+		{"exclude wins", []string{"*"}, []string{"*/private/*"}, "https://example.com/private/a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewURLFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Allow(tt.url); got != tt.want {
+				t.Errorf("Allow(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLFilterInvalidPattern(t *testing.T) {
+	if _, err := NewURLFilter(nil, []string{"re:("}); err == nil {
+		t.Error("NewURLFilter() accepted an invalid regular expression")
+	}
+}
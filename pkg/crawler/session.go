@@ -0,0 +1,423 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// session holds the state of a single CheckLinks invocation
+type session struct {
+	ctx      context.Context
+	fetcher  Fetcher
+	head     Fetcher // Lightweight fetcher for sub-resources and leaf links
+	opts     CrawlOptions
+	maxDepth int
+	sched    *scheduler
+	scope    *scopeMatcher
+	robots   *robotsCache // nil when robots.txt is ignored
+	soft     *softErrorDetector
+
+	// visited maps a normalized URL to the URL of the page crawled for it
+	visited sync.Map
+	// visitedResources does the same for sub-resources, which are checked
+	// separately so an iframe never keeps a page from being expanded
+	visitedResources sync.Map
+	results          []models.LinkStatus
+	variants         map[string][]string
+	// fragments are the links to anchors, checked against anchors once
+	// every page has been crawled
+	fragments    []fragmentRef
+	fragmentSeen map[fragmentRef]bool
+	anchors      map[string]pageAnchors
+	sources      map[sourceKey][]models.LinkSource
+	mu           sync.Mutex
+	wg           sync.WaitGroup
+
+	// headOnly are the pages checked with HEAD, loaded after the crawl
+	// when fragment links point to them
+	headOnly map[string]bool
+
+	// Progress counters
+	checked  atomic.Int64
+	queued   atomic.Int64
+	inFlight atomic.Int64
+	broken   atomic.Int64
+
+	// truncated is set once a URL is abandoned because ctx is done
+	truncated atomic.Bool
+}
+
+// sourceKey identifies the occurrences of a raw URL as one resource type
+type sourceKey struct {
+	url          string
+	resourceType string
+}
+
+func newSession(ctx context.Context, fetcher, head Fetcher, maxDepth int, opts CrawlOptions) *session {
+	var robots *robotsCache
+	if !opts.IgnoreRobots {
+		robots = newRobotsCache(opts.Browser.Timeout)
+	}
+	s := &session{
+		robots:   robots,
+		ctx:      ctx,
+		fetcher:  fetcher,
+		head:     head,
+		opts:     opts,
+		maxDepth: maxDepth,
+		// Limit concurrent requests overall and per host
+		sched:        newScheduler(opts.Browser.MaxConcurrent, opts.Politeness),
+		results:      []models.LinkStatus{},
+		variants:     make(map[string][]string),
+		anchors:      make(map[string]pageAnchors),
+		headOnly:     make(map[string]bool),
+		fragmentSeen: make(map[fragmentRef]bool),
+		sources:      make(map[sourceKey][]models.LinkSource),
+	}
+	s.soft = newSoftErrorDetector(opts.SoftErrors, s.load)
+	return s
+}
+
+func (s *session) run(baseURL string) Result {
+	s.scope = newScopeMatcher(s.opts.Scope, baseURL, s.opts.Normalize)
+
+	// Start with the base URL at depth -1
+	s.wg.Add(1)
+	go s.crawl(baseURL, "", -1, ResourcePage)
+
+	// Wait for all crawling goroutines to finish
+	s.wg.Wait()
+
+	if !s.truncated.Load() {
+		s.loadFragmentTargets()
+		if !s.abandoned() {
+			s.checkFragments()
+		}
+	}
+
+	// Report the raw URLs that were folded into each crawled URL,
+	// every place any of them was found and the pages they were found on
+	for i := range s.results {
+		result := &s.results[i]
+		variants := s.variants[result.URL]
+		if len(variants) > 1 || (len(variants) == 1 && variants[0] != result.URL) {
+			result.Variants = variants
+		}
+		if len(variants) == 0 {
+			variants = []string{result.URL}
+		}
+		for _, v := range variants {
+			result.Sources = append(result.Sources, s.sources[sourceKey{url: v, resourceType: result.ResourceType}]...)
+		}
+		for _, source := range result.Sources {
+			if !slices.Contains(result.Referrers, source.ParentURL) {
+				result.Referrers = append(result.Referrers, source.ParentURL)
+			}
+		}
+	}
+
+	return Result{
+		Links:     s.results,
+		Truncated: s.truncated.Load(),
+	}
+}
+
+// abandoned reports whether the crawl was stopped and marks it as truncated
+func (s *session) abandoned() bool {
+	if s.ctx.Err() != nil {
+		s.truncated.Store(true)
+		return true
+	}
+	return false
+}
+
+// progress returns a snapshot of the crawl counters
+func (s *session) progress() models.CrawlProgress {
+	return models.CrawlProgress{
+		Visited:  int(s.checked.Load()),
+		Queued:   int(s.queued.Load()),
+		InFlight: int(s.inFlight.Load()),
+		Broken:   int(s.broken.Load()),
+	}
+}
+
+func (s *session) notifyProgress() {
+	if s.opts.OnProgress != nil {
+		s.opts.OnProgress(s.progress())
+	}
+}
+
+// crawl checks a URL found on parentURL. Pages have their links followed;
+// sub-resources of any other type are only checked.
+func (s *session) crawl(currentURL, parentURL string, currentDepth int, resourceType string) {
+	defer s.wg.Done()
+
+	// Check depth before doing anything else
+	if currentDepth >= s.maxDepth {
+		return
+	}
+
+	if s.abandoned() {
+		return
+	}
+
+	// Check if URL was already visited under any of its variants
+	rawURL := currentURL
+	currentURL = s.opts.Normalize.Normalize(rawURL)
+	visitedURLs := &s.visited
+	if resourceType != ResourcePage {
+		visitedURLs = &s.visitedResources
+	}
+	owner, visited := visitedURLs.LoadOrStore(currentURL, currentURL)
+	s.addVariant(owner.(string), rawURL)
+	if visited {
+		return
+	}
+
+	var crawlDelay time.Duration
+	if s.robots != nil {
+		if !s.robots.Allowed(s.ctx, currentURL) {
+			log.Printf("Skipping URL disallowed by robots.txt: %s\n", currentURL)
+			s.recordSkipped(currentURL, parentURL, currentDepth, resourceType, "disallowed by robots.txt")
+			return
+		}
+		crawlDelay = s.robots.CrawlDelay(s.ctx, currentURL)
+	}
+
+	log.Printf("Crawling %s URL: %s at depth %d\n", resourceType, currentURL, currentDepth)
+
+	start := time.Now()
+
+	// Only pages whose links are followed need to be loaded; sub-resources
+	// and leaf pages are checked with lightweight requests
+	expand := resourceType == ResourcePage && currentDepth < s.maxDepth-1 && s.scope.contains(currentURL)
+	fetcher := s.fetcher
+	if resourceType != ResourcePage || (!expand && s.opts.HeadFirst && !hasFragment(rawURL)) {
+		fetcher = s.head
+	}
+
+	// Try to fetch with retries, each attempt waiting for a slot unless
+	// the crawl is stopped while waiting. The slot is free again once the
+	// fetch returns, before the soft-404 probe may need one for the same host.
+	page, attempts, queueTime, fetchErr := s.fetch(fetcher, currentURL, crawlDelay)
+	responseTime := time.Since(start) - queueTime
+
+	// A fetch cut short by the crawl stopping says nothing about the link
+	if fetchErr != nil && s.abandoned() {
+		return
+	}
+
+	if fetchErr != nil {
+		log.Printf("Error fetching %s: %v\n", currentURL, fetchErr)
+		s.recordError(currentURL, parentURL, currentDepth, resourceType, fetchErr, responseTime, queueTime, attempts)
+		return
+	}
+
+	status := models.LinkStatus{
+		URL:           currentURL,
+		ResourceType:  resourceType,
+		ParentURL:     parentURL,
+		Depth:         currentDepth + 1,
+		ResponseTime:  responseTime.String(),
+		QueueTime:     queueTime.String(),
+		LastChecked:   time.Now(),
+		StatusCode:    page.StatusCode,
+		IsWorking:     page.StatusCode >= 200 && page.StatusCode < 400,
+		ErrorCategory: ClassifyStatus(page.StatusCode),
+		Attempts:      attempts,
+	}
+
+	if len(page.Redirects) > 0 {
+		status.FinalURL = page.Redirects[len(page.Redirects)-1].Location
+		status.Redirects = page.Redirects
+		status.RedirectFlags = redirectFlags(currentURL, page.Redirects, s.opts.MaxRedirects)
+	}
+
+	// The scope follows the start page to where it redirects, like from
+	// http:// to https://www.; its links are only followed below
+	if currentDepth < 0 && status.FinalURL != "" {
+		s.scope = newScopeMatcher(s.opts.Scope, status.FinalURL, s.opts.Normalize)
+	}
+
+	if resourceType == ResourcePage {
+		if verdict := s.soft.check(s.ctx, currentURL, page); verdict != nil {
+			log.Printf("Soft 404 at %s: %s %q\n", currentURL, verdict.Rule, verdict.Detail)
+			status.SoftError = verdict
+			status.IsWorking = false
+			status.ErrorCategory = models.ErrorSoft404
+		}
+	}
+
+	log.Printf("Found %d links in %s\n", len(page.Links), currentURL)
+
+	// A not-found page has no links of its own worth following
+	expand = expand && status.SoftError == nil
+
+	if page.Canonical != "" && s.opts.Normalize.HonorCanonical {
+		canonical := s.opts.Normalize.Normalize(page.Canonical)
+		if canonical != currentURL {
+			status.CanonicalURL = canonical
+			// Pages sharing a canonical URL are duplicates, so only the first is expanded
+			if owner, claimed := s.visited.LoadOrStore(canonical, currentURL); claimed && owner != currentURL {
+				expand = false
+			}
+		}
+	}
+
+	if resourceType == ResourcePage && status.IsWorking {
+		if fetcher == s.head {
+			s.setHeadOnly(currentURL)
+		} else {
+			s.setAnchors(page, currentURL)
+		}
+	}
+
+	if expand {
+		// Launch a new goroutine for each discovered link
+		for _, link := range page.Links {
+			if !s.opts.Filter.Allow(link.URL) {
+				continue
+			}
+			s.addSource(link.URL, ResourcePage, currentURL, link.Source)
+			s.addFragmentRef(link.URL, currentURL, currentDepth+1)
+			log.Printf("Found link: %s in page %s at depth %d\n", link.URL, currentURL, currentDepth+1)
+			s.wg.Add(1)
+			go s.crawl(link.URL, currentURL, currentDepth+1, ResourcePage)
+		}
+
+		for _, r := range page.Resources {
+			if !slices.Contains(s.opts.Resources, r.Type) || !s.opts.Filter.Allow(r.URL) {
+				continue
+			}
+			s.addSource(r.URL, r.Type, currentURL, r.Source)
+			s.wg.Add(1)
+			go s.crawl(r.URL, currentURL, currentDepth+1, r.Type)
+		}
+	}
+
+	s.record(status)
+}
+
+// acquire waits for a scheduler slot for url, counting it as queued and
+// then in flight. The returned release function frees the slot.
+func (s *session) acquire(url string, crawlDelay time.Duration) (func(), time.Duration, error) {
+	queuedAt := time.Now()
+	s.queued.Add(1)
+	s.notifyProgress()
+	release, err := s.sched.acquire(s.ctx, url, crawlDelay)
+	s.queued.Add(-1)
+	if err != nil {
+		s.notifyProgress()
+		return nil, 0, err
+	}
+	queueTime := time.Since(queuedAt)
+	s.inFlight.Add(1)
+	s.notifyProgress()
+	return func() {
+		release()
+		s.inFlight.Add(-1)
+		s.notifyProgress()
+	}, queueTime, nil
+}
+
+// load fetches a page needed outside of the crawl, like the soft-404
+// probe, waiting for robots.txt and the scheduler like a crawled URL
+func (s *session) load(ctx context.Context, url string) (*Page, error) {
+	var crawlDelay time.Duration
+	if s.robots != nil {
+		if !s.robots.Allowed(ctx, url) {
+			return nil, fmt.Errorf("%s is disallowed by robots.txt", url)
+		}
+		crawlDelay = s.robots.CrawlDelay(ctx, url)
+	}
+	release, err := s.sched.acquire(ctx, url, crawlDelay)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	page, err := s.fetcher.Fetch(ctx, url, s.opts.Browser)
+	if err == nil {
+		s.sched.report(url, page.StatusCode, page.RetryAfter)
+	}
+	return page, err
+}
+
+// addSource records where a raw URL of the given resource type was found
+func (s *session) addSource(rawURL, resourceType, parentURL string, source models.LinkSource) {
+	source.ParentURL = parentURL
+	key := sourceKey{url: rawURL, resourceType: resourceType}
+	s.mu.Lock()
+	s.sources[key] = append(s.sources[key], source)
+	s.mu.Unlock()
+}
+
+// addVariant remembers that rawURL was crawled as ownerURL
+func (s *session) addVariant(ownerURL, rawURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !slices.Contains(s.variants[ownerURL], rawURL) {
+		s.variants[ownerURL] = append(s.variants[ownerURL], rawURL)
+	}
+}
+
+func (s *session) recordError(currentURL, parentURL string, depth int, resourceType string, err error, responseTime, queueTime time.Duration, attempts []models.Attempt) {
+	status := models.LinkStatus{
+		URL:           currentURL,
+		ResourceType:  resourceType,
+		ParentURL:     parentURL,
+		Depth:         depth,
+		ResponseTime:  responseTime.String(),
+		QueueTime:     queueTime.String(),
+		LastChecked:   time.Now(),
+		Error:         err.Error(),
+		ErrorCategory: ClassifyError(err),
+		IsWorking:     false,
+		Attempts:      attempts,
+	}
+
+	var redirectErr *RedirectError
+	if errors.As(err, &redirectErr) {
+		status.Redirects = redirectErr.Hops
+	}
+
+	s.record(status)
+}
+
+func (s *session) recordSkipped(currentURL, parentURL string, depth int, resourceType, reason string) {
+	status := models.LinkStatus{
+		URL:          currentURL,
+		ResourceType: resourceType,
+		ParentURL:    parentURL,
+		Depth:        depth + 1,
+		LastChecked:  time.Now(),
+		Skipped:      true,
+		SkipReason:   reason,
+	}
+
+	s.record(status)
+}
+
+func (s *session) record(status models.LinkStatus) {
+	s.mu.Lock()
+	s.results = append(s.results, status)
+	s.mu.Unlock()
+
+	s.checked.Add(1)
+	if !status.IsWorking && !status.Skipped {
+		s.broken.Add(1)
+	}
+	s.notifyProgress()
+
+	if s.opts.OnResult != nil {
+		s.opts.OnResult(status)
+	}
+}
@@ -0,0 +1,228 @@
+package crawler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// Soft error rules reported in models.SoftError
+const (
+	SoftErrorTitle       = "title_pattern"         // The title matches a not-found pattern
+	SoftErrorBody        = "body_pattern"          // The text matches a not-found pattern
+	SoftErrorTinyBody    = "tiny_body"             // The HTML is suspiciously small
+	SoftErrorFingerprint = "bogus_url_fingerprint" // The page looks like the host's answer to a URL that cannot exist
+)
+
+// fingerprintSimilarity is how much of its vocabulary a page must share
+// with the host's not-found page to be considered the same page
+const fingerprintSimilarity = 0.9
+
+// maxSoftErrorText is the most visible text a page may have for the body
+// patterns to apply; longer pages are articles that may mention "not found"
+const maxSoftErrorText = 2000
+
+// SoftErrorOptions configures soft-404 detection on pages that answer 2xx
+type SoftErrorOptions struct {
+	Enabled       bool
+	TitlePatterns []*regexp.Regexp
+	BodyPatterns  []*regexp.Regexp
+	// ProbeBogusURL requests a made-up URL once per host and flags pages
+	// that look like the answer
+	ProbeBogusURL bool
+	// MinBodySize flags pages with less HTML than this many bytes; 0 disables it
+	MinBodySize int
+}
+
+// DefaultSoftErrorOptions returns the default soft-404 heuristics. They
+// are disabled until a crawl opts in, since they can flag real pages.
+func DefaultSoftErrorOptions() SoftErrorOptions {
+	return SoftErrorOptions{
+		Enabled: false,
+		TitlePatterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\b404\b`),
+			regexp.MustCompile(`(?i)\bnot found\b`),
+			regexp.MustCompile(`(?i)\bpage (does not|doesn't) exist\b`),
+		},
+		BodyPatterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\bthe page you (are|were|'re) looking for (could not|cannot|can't|does not|doesn't)\b`),
+			regexp.MustCompile(`(?i)\b(page|file) (was )?not found\b`),
+		},
+		ProbeBogusURL: true,
+		MinBodySize:   128,
+	}
+}
+
+// SoftErrorPatterns compiles case-insensitive soft-404 patterns
+func SoftErrorPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid soft error pattern %q: %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// probeFunc loads a URL for the soft-404 probe, honoring robots.txt and
+// the crawl's politeness like any other request
+type probeFunc func(ctx context.Context, url string) (*Page, error)
+
+// softErrorDetector applies the soft-404 heuristics for one crawl and
+// caches the bogus URL probe of every host
+type softErrorDetector struct {
+	opts  SoftErrorOptions
+	fetch probeFunc
+
+	mu     sync.Mutex
+	probes map[string]*hostProbe
+}
+
+// hostProbe is how a host answers a URL that cannot exist
+type hostProbe struct {
+	once     sync.Once
+	url      string
+	page     *Page // nil when the host answers with a real error status
+	finalURL string
+	words    map[string]bool
+}
+
+func newSoftErrorDetector(opts SoftErrorOptions, fetch probeFunc) *softErrorDetector {
+	return &softErrorDetector{
+		opts:   opts,
+		fetch:  fetch,
+		probes: make(map[string]*hostProbe),
+	}
+}
+
+// check returns why a successful HTML page looks like a not-found page, or nil
+func (d *softErrorDetector) check(ctx context.Context, pageURL string, page *Page) *models.SoftError {
+	if !d.opts.Enabled || page.Content == nil || page.StatusCode < 200 || page.StatusCode >= 300 {
+		return nil
+	}
+	content := page.Content
+
+	for _, re := range d.opts.TitlePatterns {
+		if re.MatchString(content.Title) {
+			return &models.SoftError{Rule: SoftErrorTitle, Detail: content.Title}
+		}
+	}
+	for _, re := range d.opts.BodyPatterns {
+		if len(content.Text) > maxSoftErrorText {
+			break
+		}
+		if match := re.FindString(content.Text); match != "" {
+			return &models.SoftError{Rule: SoftErrorBody, Detail: match}
+		}
+	}
+	if d.opts.MinBodySize > 0 && content.Size < d.opts.MinBodySize {
+		return &models.SoftError{Rule: SoftErrorTinyBody, Detail: fmt.Sprintf("%d bytes", content.Size)}
+	}
+
+	if d.opts.ProbeBogusURL {
+		if probe := d.probe(ctx, pageURL); probe != nil && probe.matches(page) {
+			return &models.SoftError{Rule: SoftErrorFingerprint, Detail: probe.url}
+		}
+	}
+	return nil
+}
+
+// probe fetches a made-up URL on the host of pageURL once per crawl
+func (d *softErrorDetector) probe(ctx context.Context, pageURL string) *hostProbe {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	d.mu.Lock()
+	probe, ok := d.probes[origin]
+	if !ok {
+		probe = &hostProbe{}
+		d.probes[origin] = probe
+	}
+	d.mu.Unlock()
+
+	probe.once.Do(func() {
+		b := make([]byte, 8)
+		rand.Read(b)
+		probe.url = origin + "/" + hex.EncodeToString(b) + "-does-not-exist"
+
+		page, err := d.fetch(ctx, probe.url)
+		if err != nil {
+			log.Printf("Error probing %s for soft 404s: %v\n", origin, err)
+			return
+		}
+		if page.StatusCode < 200 || page.StatusCode >= 300 || page.Content == nil {
+			return
+		}
+		probe.page = page
+		probe.finalURL = finalURL(page)
+		probe.words = wordSet(page.Content.Text)
+
+		// Hosts serving the same page for every URL, like single page apps
+		// fetched without a browser, cannot be judged by fingerprint
+		if root, err := d.fetch(ctx, origin+"/"); err == nil && root.Content != nil && probe.matches(root) {
+			log.Printf("Host %s serves the same page for every URL, skipping soft 404 fingerprints\n", origin)
+			probe.page = nil
+			return
+		}
+		log.Printf("Host %s answers %d to unknown URLs, comparing pages with its not-found page\n", origin, page.StatusCode)
+	})
+
+	if probe.page == nil {
+		return nil
+	}
+	return probe
+}
+
+// matches reports whether page is the same page the host served for the bogus URL
+func (p *hostProbe) matches(page *Page) bool {
+	// Hosts that redirect unknown URLs somewhere only match pages redirected there too
+	if len(p.page.Redirects) > 0 {
+		return len(page.Redirects) > 0 && finalURL(page) == p.finalURL
+	}
+	if len(p.words) == 0 {
+		return false
+	}
+	return similarity(p.words, wordSet(page.Content.Text)) >= fingerprintSimilarity
+}
+
+func finalURL(page *Page) string {
+	if n := len(page.Redirects); n > 0 {
+		return page.Redirects[n-1].Location
+	}
+	return page.URL
+}
+
+func wordSet(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		words[w] = true
+	}
+	return words
+}
+
+// similarity is the Jaccard index of two word sets
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
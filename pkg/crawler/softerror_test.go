@@ -0,0 +1,141 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSoftErrorRules(t *testing.T) {
+	article := strings.Repeat("A long article about error pages. ", 100) + "The page was not found."
+	tests := []struct {
+		name    string
+		content PageContent
+		want    string
+	}{
+		{"title", PageContent{Title: "404 - Example", Text: strings.Repeat("x ", 100), Size: 1000}, SoftErrorTitle},
+		{"short body", PageContent{Title: "Example", Text: "Sorry, the page was not found here.", Size: 1000}, SoftErrorBody},
+		{"long body", PageContent{Title: "Example", Text: article, Size: 5000}, ""},
+		{"tiny body", PageContent{Title: "Example", Text: "Hi", Size: 20}, SoftErrorTinyBody},
+		{"regular page", PageContent{Title: "Example", Text: "Welcome to our docs", Size: 1000}, ""},
+	}
+	opts := DefaultSoftErrorOptions()
+	opts.Enabled = true
+	opts.ProbeBogusURL = false
+	d := newSoftErrorDetector(opts, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := tt.content
+			verdict := d.check(context.Background(), "https://example.com/page", &Page{StatusCode: 200, Content: &content})
+			got := ""
+			if verdict != nil {
+				got = verdict.Rule
+			}
+			if got != tt.want {
+				t.Errorf("rule = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSoftErrorsAreOptIn(t *testing.T) {
+	srv := serveSite(t, map[string]string{
+		"/":           linkPage("/gone"),
+		"/gone":       "<html><head><title>404 Not Found</title></head><body>Page not found</body></html>",
+		"/robots.txt": "",
+	})
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 3, testOptions())
+
+	for _, l := range result.Links {
+		if l.SoftError != nil {
+			t.Errorf("%s flagged as %s without opting in", l.URL, l.SoftError.Rule)
+		}
+	}
+}
+
+// fingerprintSite answers every unknown URL with the same 200 page and
+// records the paths requested
+func fingerprintSite(t *testing.T, robots string) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var paths []string
+	pages := map[string]string{
+		"/":     linkPage("/real", "/gone"),
+		"/real": "<html><head><title>Real</title></head><body><p>Installation guide for the command line tool and its options</p></body></html>",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, robots)
+			return
+		}
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if page, ok := pages[r.URL.Path]; ok {
+			fmt.Fprint(w, page)
+			return
+		}
+		fmt.Fprint(w, "<html><head><title>Example</title></head><body><p>Sorry, we could not find anything at this address</p></body></html>")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), paths...)
+	}
+}
+
+// probeOptions only enable the bogus URL probe, with one request at a time
+// so a probe waiting on a held slot would never finish
+func probeOptions() CrawlOptions {
+	opts := testOptions()
+	opts.HeadFirst = false
+	opts.Browser.MaxConcurrent = 1
+	opts.Politeness.MaxPerHost = 1
+	opts.SoftErrors = SoftErrorOptions{Enabled: true, ProbeBogusURL: true}
+	return opts
+}
+
+func TestSoftErrorProbeFingerprint(t *testing.T) {
+	srv, _ := fingerprintSite(t, "")
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 2, probeOptions())
+
+	flagged := map[string]bool{}
+	for _, l := range result.Links {
+		if l.SoftError != nil && l.SoftError.Rule == SoftErrorFingerprint {
+			flagged[strings.TrimPrefix(l.URL, srv.URL)] = true
+		}
+	}
+	if !flagged["/gone"] || flagged["/real"] || flagged["/"] {
+		t.Errorf("flagged %v, want only /gone", flagged)
+	}
+}
+
+func TestSoftErrorProbeHonorsRobots(t *testing.T) {
+	srv, requested := fingerprintSite(t, "User-agent: *\nDisallow: /*-does-not-exist$")
+
+	c := NewCrawlerWithFetcher(NewHTTPFetcher())
+	defer c.Close()
+	result := c.CheckLinksWithOptions(context.Background(), srv.URL+"/", 2, probeOptions())
+
+	for _, path := range requested() {
+		if strings.HasSuffix(path, "-does-not-exist") {
+			t.Errorf("probe requested %s disallowed by robots.txt", path)
+		}
+	}
+	for _, l := range result.Links {
+		if l.SoftError != nil {
+			t.Errorf("%s flagged as %s without a probe", l.URL, l.SoftError.Rule)
+		}
+	}
+}
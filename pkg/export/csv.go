@@ -0,0 +1,107 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// csvExporter writes one row per link for spreadsheets
+type csvExporter struct{}
+
+var csvHeader = []string{
+	"url", "status_code", "is_working", "error_category", "error", "resource_type",
+	"depth", "parent_url", "referrers", "response_time", "final_url", "redirect_flags",
+	"skipped", "skip_reason", "last_checked",
+}
+
+func (csvExporter) ContentType() string { return "text/csv" }
+
+// csvDiffHeader is used instead of csvHeader when exporting a diff
+var csvDiffHeader = []string{
+	"change", "url", "resource_type", "status_before", "status_after", "status_changed",
+	"error_category", "response_time_before", "response_time_after", "slower",
+}
+
+func (csvExporter) Export(w io.Writer, report Report) error {
+	cw := csv.NewWriter(w)
+	if report.Diff != nil {
+		if err := cw.Write(csvDiffHeader); err != nil {
+			return err
+		}
+		for _, c := range report.Diff.Changes {
+			if err := cw.Write(csvDiffRow(c)); err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, l := range report.Links {
+			if err := cw.Write(csvRow(l)); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvRow(l models.LinkStatus) []string {
+	errText := l.Error
+	if errText == "" && broken(l) {
+		errText = Problem(l)
+	}
+	lastChecked := ""
+	if !l.LastChecked.IsZero() {
+		lastChecked = l.LastChecked.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		l.URL,
+		strconv.Itoa(l.StatusCode),
+		strconv.FormatBool(l.IsWorking),
+		string(l.ErrorCategory),
+		errText,
+		l.ResourceType,
+		strconv.Itoa(l.Depth),
+		l.ParentURL,
+		strings.Join(l.Referrers, " "),
+		l.ResponseTime,
+		l.FinalURL,
+		strings.Join(l.RedirectFlags, " "),
+		strconv.FormatBool(l.Skipped),
+		l.SkipReason,
+		lastChecked,
+	}
+}
+
+func csvDiffRow(c models.LinkChange) []string {
+	var statusBefore, statusAfter, timeBefore, timeAfter, category string
+	if c.Before != nil {
+		statusBefore = strconv.Itoa(c.Before.StatusCode)
+		timeBefore = c.Before.ResponseTime
+		category = string(c.Before.ErrorCategory)
+	}
+	if c.After != nil {
+		statusAfter = strconv.Itoa(c.After.StatusCode)
+		timeAfter = c.After.ResponseTime
+		category = string(c.After.ErrorCategory)
+	}
+	return []string{
+		string(c.Change),
+		c.URL,
+		c.ResourceType,
+		statusBefore,
+		statusAfter,
+		strconv.FormatBool(c.StatusChanged),
+		category,
+		timeBefore,
+		timeAfter,
+		strconv.FormatBool(c.Slower),
+	}
+}
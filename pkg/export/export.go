@@ -0,0 +1,132 @@
+// Package export writes crawl results in formats understood by CI
+// dashboards, code scanning, spreadsheets and people
+package export
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// Export formats
+const (
+	FormatJSON     = "json"
+	FormatJUnit    = "junit"
+	FormatSARIF    = "sarif"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Report is a finished or partial crawl to export
+type Report struct {
+	URL         string              `json:"url"`
+	Links       []models.LinkStatus `json:"links"`
+	Truncated   bool                `json:"truncated"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	// Diff, if set, compares Links with an earlier run; exporters then
+	// focus on what changed
+	Diff *models.RunDiff `json:"diff,omitempty"`
+	// SourceRoot, if set, is the repository directory the site at URL is
+	// served from, so SARIF results point at its files instead of URLs
+	SourceRoot string `json:"-"`
+}
+
+// Exporter writes a report in one format
+type Exporter interface {
+	ContentType() string
+	Export(w io.Writer, report Report) error
+}
+
+// exporters maps every format name to its exporter
+var exporters = map[string]Exporter{
+	FormatJSON:     jsonExporter{},
+	FormatJUnit:    junitExporter{},
+	FormatSARIF:    sarifExporter{},
+	FormatCSV:      csvExporter{},
+	FormatMarkdown: markdownExporter{},
+	FormatHTML:     htmlExporter{},
+}
+
+// Formats returns the names of every export format, sorted
+func Formats() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the exporter of a format
+func Get(format string) (Exporter, error) {
+	e, ok := exporters[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, use one of %s", format, strings.Join(Formats(), ", "))
+	}
+	return e, nil
+}
+
+// Negotiate returns the format of the first media type in an Accept
+// header some exporter produces, or "" when none matches
+func Negotiate(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		for name, e := range exporters {
+			if e.ContentType() == mediaType {
+				return name
+			}
+		}
+		// Also accept the more specific JUnit media type
+		if mediaType == "application/junit+xml" {
+			return FormatJUnit
+		}
+	}
+	return ""
+}
+
+// Problem describes why a link is broken in a few words
+func Problem(l models.LinkStatus) string {
+	switch {
+	case l.Error != "":
+		return l.Error
+	case l.SoftError != nil:
+		return fmt.Sprintf("soft 404 (%s): %s", l.SoftError.Rule, l.SoftError.Detail)
+	case l.StatusCode != 0:
+		return fmt.Sprintf("HTTP %d", l.StatusCode)
+	default:
+		return "broken link"
+	}
+}
+
+// changes maps every link of the newer run to how it changed, keyed by
+// changeKey; it is empty without a diff
+func (r Report) changes() map[string]models.LinkChangeKind {
+	kinds := make(map[string]models.LinkChangeKind)
+	if r.Diff == nil {
+		return kinds
+	}
+	for _, c := range r.Diff.Changes {
+		if c.After != nil {
+			kinds[changeKey(*c.After)] = c.Change
+		}
+	}
+	return kinds
+}
+
+func changeKey(l models.LinkStatus) string {
+	return l.ResourceType + " " + l.URL
+}
+
+// broken reports whether a link was checked and failed
+func broken(l models.LinkStatus) bool {
+	return !l.IsWorking && !l.Skipped
+}
@@ -0,0 +1,145 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testReport has a working page, broken links found on one and two pages,
+// a skipped link and text that needs escaping in every format
+func testReport() Report {
+	checked := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return Report{
+		URL:         "https://example.com/",
+		GeneratedAt: checked,
+		Links: []models.LinkStatus{
+			{
+				URL: "https://example.com/", StatusCode: 200, IsWorking: true, ResourceType: "page",
+				ResponseTime: "120ms", LastChecked: checked,
+			},
+			{
+				URL: "https://example.com/old?a=1&b=2", StatusCode: 404, ErrorCategory: models.ErrorHTTPClient,
+				ResourceType: "page", Depth: 1, ParentURL: "https://example.com/docs/",
+				Referrers:    []string{"https://example.com/docs/", "https://example.com/blog/post.html"},
+				ResponseTime: "35ms", LastChecked: checked,
+				Sources: []models.LinkSource{
+					{ParentURL: "https://example.com/docs/", Text: `The "old" <page>`, Line: 12, Column: 5},
+					{ParentURL: "https://example.com/blog/post.html", Text: "old page", Line: 40, Column: 9},
+				},
+			},
+			{
+				URL: "https://down.example.org/", Error: "dial tcp: connection refused,\n\"retry later\"",
+				ErrorCategory: models.ErrorConnectionRefused, ResourceType: "image", Depth: 1,
+				ParentURL: "https://example.com/docs/", ResponseTime: "2ms", LastChecked: checked,
+				Sources: []models.LinkSource{{ParentURL: "https://example.com/docs/", Text: "diagram & chart"}},
+			},
+			{
+				URL: "https://example.com/private/", Skipped: true, SkipReason: "disallowed by robots.txt",
+				ResourceType: "page", Depth: 1, ParentURL: "https://example.com/", ResponseTime: "0s",
+			},
+		},
+	}
+}
+
+func TestExportGolden(t *testing.T) {
+	withSourceRoot := testReport()
+	withSourceRoot.SourceRoot = "public"
+
+	tests := []struct {
+		golden string
+		format string
+		report Report
+	}{
+		{"report.sarif", FormatSARIF, testReport()},
+		{"report_source_root.sarif", FormatSARIF, withSourceRoot},
+		{"report.junit.xml", FormatJUnit, testReport()},
+		{"report.csv", FormatCSV, testReport()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			e, err := Get(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := e.Export(&buf, tt.report); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output differs from %s (run go test -update to accept it):\n%s", path, buf.String())
+			}
+		})
+	}
+}
+
+func TestCSVEscaping(t *testing.T) {
+	report := testReport()
+	var buf bytes.Buffer
+	if err := (csvExporter{}).Export(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(rows) != len(report.Links)+1 {
+		t.Fatalf("got %d rows, want a header and %d links", len(rows), len(report.Links))
+	}
+	// Every field survives a round trip, including commas, quotes and newlines
+	for i, l := range report.Links {
+		row := rows[i+1]
+		if row[0] != l.URL {
+			t.Errorf("row %d url = %q, want %q", i, row[0], l.URL)
+		}
+		if l.Error != "" && row[4] != l.Error {
+			t.Errorf("row %d error = %q, want %q", i, row[4], l.Error)
+		}
+	}
+}
+
+func TestSARIFArtifactOf(t *testing.T) {
+	report := Report{URL: "https://example.com/docs/", SourceRoot: "site"}
+	tests := []struct {
+		name   string
+		report Report
+		page   string
+		want   string
+		wantID string
+	}{
+		{"no source root", Report{URL: report.URL}, "https://example.com/a.html", "https://example.com/a.html", ""},
+		{"file", report, "https://example.com/docs/a.html", "site/docs/a.html", sarifSourceRoot},
+		{"directory", report, "https://example.com/docs/", "site/docs/index.html", sarifSourceRoot},
+		{"root", report, "https://example.com", "site/index.html", sarifSourceRoot},
+		{"escaped", report, "https://example.com/my%20page.html", "site/my%20page.html", sarifSourceRoot},
+		{"other host", report, "https://other.org/a.html", "https://other.org/a.html", ""},
+		{"other scheme", report, "http://example.com/a.html", "http://example.com/a.html", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sarifArtifactOf(tt.report, tt.page)
+			if got.URI != tt.want || got.URIBaseID != tt.wantID {
+				t.Errorf("sarifArtifactOf(%q) = %+v, want %q relative to %q", tt.page, got, tt.want, tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package export
+
+import (
+	"html/template"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// htmlExporter writes a self-contained page with the summary and every
+// link, broken ones first
+type htmlExporter struct{}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"problem": Problem,
+	"broken":  broken,
+	"count": func(diff *models.RunDiff, kind string) int {
+		return diff.Counts[models.LinkChangeKind(kind)]
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Link check for {{.URL}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2937; }
+h1 { font-size: 1.4rem; word-break: break-all; }
+h2 { font-size: 1.1rem; margin-top: 2rem; }
+.summary { display: flex; gap: 1rem; margin: 1rem 0; }
+.summary div { padding: .75rem 1rem; border-radius: .5rem; background: #f3f4f6; }
+.summary strong { display: block; font-size: 1.5rem; }
+.warning { padding: .75rem 1rem; border-radius: .5rem; background: #fef3c7; }
+table { border-collapse: collapse; width: 100%; font-size: .9rem; }
+th, td { text-align: left; padding: .4rem .6rem; border-bottom: 1px solid #e5e7eb; vertical-align: top; word-break: break-all; }
+tr.broken td:first-child { color: #b91c1c; font-weight: bold; }
+tr.skipped { color: #6b7280; }
+</style>
+</head>
+<body>
+<h1>Link check for <a href="{{.URL}}">{{.URL}}</a></h1>
+<p>Generated {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+<div class="summary">
+<div><strong>{{.Summary.Total}}</strong>checked</div>
+<div><strong>{{.Summary.Working}}</strong>working</div>
+<div><strong>{{.Summary.Broken}}</strong>broken</div>
+<div><strong>{{.Summary.Skipped}}</strong>skipped</div>
+</div>
+{{if .Truncated}}<p class="warning">The crawl was stopped early; results are partial.</p>{{end}}
+{{with .Diff}}<h2>Changes since run {{.Base}}</h2>
+<div class="summary">
+<div><strong>{{count . "newly_broken"}}</strong>newly broken</div>
+<div><strong>{{count . "fixed"}}</strong>fixed</div>
+<div><strong>{{count . "still_broken"}}</strong>still broken</div>
+<div><strong>{{count . "discovered"}}</strong>discovered</div>
+<div><strong>{{count . "disappeared"}}</strong>disappeared</div>
+<div><strong>{{.Slower}}</strong>slower</div>
+</div>
+<table>
+<thead><tr><th>Change</th><th>URL</th><th>Before</th><th>Now</th><th>Response time</th></tr></thead>
+<tbody>
+{{range .Changes}}<tr class="{{if eq .Change "newly_broken" "still_broken"}}broken{{end}}">
+<td>{{.Change}}{{if .StatusChanged}}, status changed{{end}}{{if .Slower}}, slower{{end}}</td>
+<td><a href="{{.URL}}">{{.URL}}</a></td>
+<td>{{with .Before}}{{if .StatusCode}}{{.StatusCode}}{{else}}{{.ErrorCategory}}{{end}}{{end}}</td>
+<td>{{with .After}}{{if .StatusCode}}{{.StatusCode}}{{else}}{{.ErrorCategory}}{{end}}{{end}}</td>
+<td>{{with .Before}}{{.ResponseTime}}{{end}}{{if and .Before .After}} → {{end}}{{with .After}}{{.ResponseTime}}{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+<h2>All links</h2>
+{{end}}<table>
+<thead><tr><th>Status</th><th>URL</th><th>Found on</th><th>Type</th><th>Time</th><th>Problem</th></tr></thead>
+<tbody>
+{{range .Links}}<tr class="{{if .Skipped}}skipped{{else if broken .}}broken{{end}}">
+<td>{{if .Skipped}}skipped{{else if .StatusCode}}{{.StatusCode}}{{else}}{{.ErrorCategory}}{{end}}</td>
+<td><a href="{{.URL}}">{{.URL}}</a></td>
+<td>{{if .ParentURL}}<a href="{{.ParentURL}}">{{.ParentURL}}</a>{{end}}</td>
+<td>{{.ResourceType}}</td>
+<td>{{.ResponseTime}}</td>
+<td>{{if .Skipped}}{{.SkipReason}}{{else if broken .}}{{problem .}}{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+`))
+
+func (htmlExporter) ContentType() string { return "text/html" }
+
+func (htmlExporter) Export(w io.Writer, report Report) error {
+	links := slices.Clone(report.Links)
+	slices.SortStableFunc(links, func(a, b models.LinkStatus) int {
+		return rank(a) - rank(b)
+	})
+
+	return htmlTemplate.Execute(w, struct {
+		URL         string
+		GeneratedAt time.Time
+		Summary     models.LinkSummary
+		Truncated   bool
+		Diff        *models.RunDiff
+		Links       []models.LinkStatus
+	}{report.URL, report.GeneratedAt, models.Summarize(report.Links), report.Truncated, report.Diff, links})
+}
+
+// rank orders broken links before working and skipped ones
+func rank(l models.LinkStatus) int {
+	switch {
+	case broken(l):
+		return 0
+	case l.Skipped:
+		return 2
+	default:
+		return 1
+	}
+}
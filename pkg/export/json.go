@@ -0,0 +1,17 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonExporter writes the report as indented JSON
+type jsonExporter struct{}
+
+func (jsonExporter) ContentType() string { return "application/json" }
+
+func (jsonExporter) Export(w io.Writer, report Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,138 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// junitExporter writes one test case per link so CI dashboards list
+// every broken link as a failed test
+type junitExporter struct{}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+func (junitExporter) ContentType() string { return "application/xml" }
+
+func (junitExporter) Export(w io.Writer, report Report) error {
+	summary := models.Summarize(report.Links)
+	var total time.Duration
+	suite := junitSuite{
+		Name:      report.URL,
+		Tests:     summary.Total,
+		Failures:  summary.Broken,
+		Skipped:   summary.Skipped,
+		Timestamp: report.GeneratedAt.UTC().Format(time.RFC3339),
+		Cases:     make([]junitCase, 0, len(report.Links)),
+	}
+
+	changes := report.changes()
+	for _, l := range report.Links {
+		d, _ := time.ParseDuration(l.ResponseTime)
+		total += d
+		tc := junitCase{
+			Name:      l.URL,
+			ClassName: l.ResourceType,
+			Time:      seconds(d),
+		}
+		switch {
+		case l.Skipped:
+			tc.Skipped = &junitSkipped{Message: l.SkipReason}
+		case broken(l) && changes[changeKey(l)] == models.ChangeStillBroken:
+			// Compared with an earlier run, only new breakage fails the suite
+			tc.Skipped = &junitSkipped{Message: "already broken in run " + report.Diff.Base + ": " + Problem(l)}
+			suite.Failures--
+			suite.Skipped++
+		case broken(l):
+			tc.Failure = &junitFailure{
+				Message: Problem(l),
+				Type:    string(l.ErrorCategory),
+				Details: failureDetails(l),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{
+		Name:     "broken-links-tester",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// failureDetails lists where a broken link was found
+func failureDetails(l models.LinkStatus) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n", l.URL, Problem(l))
+	if len(l.Sources) == 0 && l.ParentURL != "" {
+		fmt.Fprintf(&b, "found on %s\n", l.ParentURL)
+	}
+	for _, src := range l.Sources {
+		fmt.Fprintf(&b, "found on %s", src.ParentURL)
+		if src.Line > 0 {
+			fmt.Fprintf(&b, " at line %d, column %d", src.Line, src.Column)
+		}
+		if src.Text != "" {
+			fmt.Fprintf(&b, " as %q", src.Text)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
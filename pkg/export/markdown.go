@@ -0,0 +1,134 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// maxMarkdownRows caps the broken links listed so a comment stays readable
+const maxMarkdownRows = 50
+
+// markdownExporter writes a short summary suited for pull request comments
+type markdownExporter struct{}
+
+func (markdownExporter) ContentType() string { return "text/markdown" }
+
+func (markdownExporter) Export(w io.Writer, report Report) error {
+	summary := models.Summarize(report.Links)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Link check for %s\n\n", report.URL)
+	if summary.Broken == 0 {
+		b.WriteString(":white_check_mark: No broken links found.\n\n")
+	} else {
+		fmt.Fprintf(&b, ":x: %d broken %s found.\n\n", summary.Broken, plural(summary.Broken, "link", "links"))
+	}
+	b.WriteString("| Checked | Working | Broken | Skipped |\n")
+	b.WriteString("| ------: | ------: | -----: | ------: |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d |\n", summary.Total, summary.Working, summary.Broken, summary.Skipped)
+	if report.Truncated {
+		b.WriteString("\n> The crawl was stopped early; results are partial.\n")
+	}
+
+	if report.Diff != nil {
+		writeMarkdownDiff(&b, report.Diff)
+	} else if summary.Broken > 0 {
+		b.WriteString("\n### Broken links\n\n")
+		b.WriteString("| Status | URL | Found on | Problem |\n")
+		b.WriteString("| ------ | --- | -------- | ------- |\n")
+		rows := 0
+		for _, l := range report.Links {
+			if !broken(l) {
+				continue
+			}
+			if rows == maxMarkdownRows {
+				fmt.Fprintf(&b, "\n…and %d more.\n", summary.Broken-rows)
+				break
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+				markdownStatus(l), markdownCell(l.URL), markdownCell(foundOn(l)), markdownCell(Problem(l)))
+			rows++
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownDiff lists the links that broke or got fixed since the base run
+func writeMarkdownDiff(b *strings.Builder, diff *models.RunDiff) {
+	fmt.Fprintf(b, "\n### Changes since run %s\n\n", diff.Base)
+	b.WriteString("| Newly broken | Fixed | Still broken | Discovered | Disappeared | Slower |\n")
+	b.WriteString("| -----------: | ----: | -----------: | ---------: | ----------: | -----: |\n")
+	fmt.Fprintf(b, "| %d | %d | %d | %d | %d | %d |\n",
+		diff.Counts[models.ChangeNewlyBroken], diff.Counts[models.ChangeFixed], diff.Counts[models.ChangeStillBroken],
+		diff.Counts[models.ChangeDiscovered], diff.Counts[models.ChangeDisappeared], diff.Slower)
+
+	for _, section := range []struct {
+		kind  models.LinkChangeKind
+		title string
+	}{
+		{models.ChangeNewlyBroken, "Newly broken"},
+		{models.ChangeFixed, "Fixed"},
+	} {
+		n := diff.Counts[section.kind]
+		if n == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "\n#### %s\n\n", section.title)
+		b.WriteString("| Before | Now | URL | Found on |\n")
+		b.WriteString("| ------ | --- | --- | -------- |\n")
+		rows := 0
+		for _, c := range diff.Changes {
+			if c.Change != section.kind {
+				continue
+			}
+			if rows == maxMarkdownRows {
+				fmt.Fprintf(b, "\n…and %d more.\n", n-rows)
+				break
+			}
+			before := "new"
+			if c.Before != nil {
+				before = markdownStatus(*c.Before)
+			}
+			fmt.Fprintf(b, "| %s | %s | %s | %s |\n",
+				before, markdownStatus(*c.After), markdownCell(c.URL), markdownCell(foundOn(*c.After)))
+			rows++
+		}
+	}
+}
+
+func markdownStatus(l models.LinkStatus) string {
+	if l.StatusCode != 0 {
+		return fmt.Sprint(l.StatusCode)
+	}
+	return "`" + string(l.ErrorCategory) + "`"
+}
+
+// markdownCell escapes text for a table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// foundOn names the pages linking to a URL
+func foundOn(l models.LinkStatus) string {
+	switch len(l.Referrers) {
+	case 0:
+		return l.ParentURL
+	case 1:
+		return l.Referrers[0]
+	default:
+		return fmt.Sprintf("%s and %d more", l.Referrers[0], len(l.Referrers)-1)
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
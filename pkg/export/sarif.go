@@ -0,0 +1,219 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifBrokenLink is the rule of broken links without an error category
+	sarifBrokenLink = "broken_link"
+	// sarifSourceRoot is the base ID code scanning resolves against the
+	// root of the repository
+	sarifSourceRoot = "%SRCROOT%"
+)
+
+// sarifExporter writes broken links as SARIF results so code scanning
+// tools can annotate the pages linking to them
+type sarifExporter struct{}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID string `json:"ruleId"`
+	Level  string `json:"level"`
+	// BaselineState is new, unchanged or absent when comparing with an earlier run
+	BaselineState string          `json:"baselineState,omitempty"`
+	Message       sarifMessage    `json:"message"`
+	Locations     []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifRules describes every rule a result can refer to
+var sarifRules = map[string]string{
+	string(models.ErrorDNS):               "The host name of the link does not resolve",
+	string(models.ErrorTLS):               "The TLS handshake with the linked host fails",
+	string(models.ErrorConnectionRefused): "The linked host refuses connections",
+	string(models.ErrorConnectionReset):   "The linked host resets the connection",
+	string(models.ErrorTimeout):           "The link does not answer in time",
+	string(models.ErrorRedirectLoop):      "The link redirects in a loop",
+	string(models.ErrorHTTPClient):        "The link answers with a client error",
+	string(models.ErrorHTTPServer):        "The link answers with a server error",
+	string(models.ErrorBrokenFragment):    "The anchor the link points to does not exist",
+	string(models.ErrorSoft404):           "The link leads to a not-found page answering 2xx",
+	string(models.ErrorNetwork):           "The link fails with a network error",
+	string(models.ErrorUnknown):           "The link fails for an unknown reason",
+	sarifBrokenLink:                       "The link is broken",
+}
+
+func (sarifExporter) ContentType() string { return "application/sarif+json" }
+
+func (sarifExporter) Export(w io.Writer, report Report) error {
+	results := make([]sarifResult, 0)
+	used := make(map[string]bool)
+	add := func(l models.LinkStatus, baselineState string) {
+		rule := string(l.ErrorCategory)
+		if _, ok := sarifRules[rule]; !ok {
+			rule = sarifBrokenLink
+		}
+		used[rule] = true
+		results = append(results, sarifResult{
+			RuleID:        rule,
+			Level:         "error",
+			BaselineState: baselineState,
+			Message:       sarifMessage{Text: l.URL + ": " + Problem(l)},
+			Locations:     sarifLocations(report, l),
+		})
+	}
+
+	changes := report.changes()
+	for _, l := range report.Links {
+		if !broken(l) {
+			continue
+		}
+		state := ""
+		if report.Diff != nil {
+			state = "new"
+			if changes[changeKey(l)] == models.ChangeStillBroken {
+				state = "unchanged"
+			}
+		}
+		add(l, state)
+	}
+	// Links broken in the earlier run that got fixed, are gone or were skipped
+	if report.Diff != nil {
+		for _, c := range report.Diff.Changes {
+			if c.Before != nil && broken(*c.Before) && (c.Change == models.ChangeFixed || c.Change == models.ChangeDisappeared || c.Change == models.ChangeSkipped) {
+				add(*c.Before, "absent")
+			}
+		}
+	}
+
+	// List the rules in the order of the categories
+	rules := make([]sarifRule, 0, len(used))
+	for _, category := range slices.Concat(models.ErrorCategories, []models.ErrorCategory{sarifBrokenLink}) {
+		if used[string(category)] {
+			rules = append(rules, sarifRule{
+				ID:               string(category),
+				ShortDescription: sarifMessage{Text: sarifRules[string(category)]},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "broken-links-tester",
+				InformationURI: "https://github.com/aocamilo/broken-links-tester",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	})
+}
+
+// sarifLocations points at every place a link was found; the start URL
+// has no source and points at itself
+func sarifLocations(report Report, l models.LinkStatus) []sarifLocation {
+	var locations []sarifLocation
+	for _, src := range l.Sources {
+		loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactOf(report, src.ParentURL),
+		}}
+		if src.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: src.Line, StartColumn: src.Column}
+		}
+		locations = append(locations, loc)
+	}
+	if len(locations) == 0 {
+		uri := l.ParentURL
+		if uri == "" {
+			uri = l.URL
+		}
+		locations = append(locations, sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactOf(report, uri),
+		}})
+	}
+	return locations
+}
+
+// sarifArtifactOf locates a page as a file below the report's SourceRoot
+// when it is on the same site as the report URL, and by its URL otherwise.
+// Directory URLs map to their index.html.
+func sarifArtifactOf(report Report, pageURL string) sarifArtifact {
+	if report.SourceRoot == "" {
+		return sarifArtifact{URI: pageURL}
+	}
+	site, err := url.Parse(report.URL)
+	if err != nil {
+		return sarifArtifact{URI: pageURL}
+	}
+	page, err := url.Parse(pageURL)
+	if err != nil || !strings.EqualFold(page.Scheme, site.Scheme) || !strings.EqualFold(page.Host, site.Host) {
+		return sarifArtifact{URI: pageURL}
+	}
+	file := page.Path
+	if file == "" || strings.HasSuffix(file, "/") {
+		file += "index.html"
+	}
+	file = path.Join(report.SourceRoot, file)
+	return sarifArtifact{URI: (&url.URL{Path: file}).EscapedPath(), URIBaseID: sarifSourceRoot}
+}
@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"maps"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// Event names delivered to stream subscribers
+const (
+	EventResult   = "result"
+	EventProgress = "progress"
+	EventDone     = "done"
+)
+
+// subscriberBuffer is how many events a subscriber may lag behind before
+// it is dropped; a dropped client can resubscribe and replay the results
+const subscriberBuffer = 1024
+
+// Event is a job update delivered to stream subscribers
+type Event struct {
+	Name string
+	Data interface{}
+}
+
+// Subscribe returns the results recorded so far together with a channel
+// that receives every later update. The channel is closed after the
+// EventDone event, or early if the subscriber falls too far behind.
+// Call unsubscribe once the stream is no longer read.
+func (r *Registry) Subscribe(id string) (replay []models.LinkStatus, events <-chan Event, unsubscribe func(), err error) {
+	r.mu.Lock()
+	j, ok := r.jobs[id]
+	r.mu.Unlock()
+	if !ok {
+		return nil, nil, nil, ErrNotFound
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	replay = j.info.Results
+	if j.info.State.Finished() {
+		ch <- Event{Name: EventDone, Data: j.summary()}
+		close(ch)
+		return replay, ch, func() {}, nil
+	}
+
+	j.subs[ch] = struct{}{}
+	unsubscribe = func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := j.subs[ch]; ok {
+			delete(j.subs, ch)
+			close(ch)
+		}
+	}
+	return replay, ch, unsubscribe, nil
+}
+
+func (j *job) addResult(status models.LinkStatus) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.info.State != models.JobRunning {
+		return
+	}
+	j.info.Results = append(j.info.Results, status)
+	if status.ErrorCategory != "" {
+		if j.info.ErrorCounts == nil {
+			j.info.ErrorCounts = make(map[models.ErrorCategory]int)
+		}
+		j.info.ErrorCounts[status.ErrorCategory]++
+	}
+	j.publish(Event{Name: EventResult, Data: status})
+}
+
+// publish delivers an event to every subscriber; j.mu must be held
+func (j *job) publish(ev Event) {
+	for ch := range j.subs {
+		select {
+		case ch <- ev:
+		default:
+			// Drop subscribers that cannot keep up instead of blocking the crawl
+			delete(j.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// closeSubscribers sends the final state and ends every stream; j.mu must be held
+func (j *job) closeSubscribers() {
+	j.publish(Event{Name: EventDone, Data: j.summary()})
+	for ch := range j.subs {
+		delete(j.subs, ch)
+		close(ch)
+	}
+}
+
+// summary returns the job without its results; j.mu must be held
+func (j *job) summary() models.Job {
+	info := j.info
+	info.Results = nil
+	info.ErrorCounts = maps.Clone(info.ErrorCounts)
+	return info
+}
@@ -0,0 +1,189 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+)
+
+// feedRunner reports every status sent on feed as a result and returns
+// them all once feed is closed, or as truncated once ctx is cancelled
+type feedRunner struct {
+	feed chan models.LinkStatus
+}
+
+func newFeedRunner() feedRunner {
+	return feedRunner{feed: make(chan models.LinkStatus)}
+}
+
+func (r feedRunner) CheckLinksWithOptions(ctx context.Context, baseURL string, maxDepth int, opts crawler.CrawlOptions) crawler.Result {
+	var result crawler.Result
+	for {
+		select {
+		case status, ok := <-r.feed:
+			if !ok {
+				return result
+			}
+			opts.OnResult(status)
+			result.Links = append(result.Links, status)
+		case <-ctx.Done():
+			result.Truncated = true
+			return result
+		}
+	}
+}
+
+func status(i int) models.LinkStatus {
+	return models.LinkStatus{URL: fmt.Sprintf("https://example.com/%d", i), StatusCode: 200, IsWorking: true}
+}
+
+// waitForResults waits until the job recorded n results
+func waitForResults(t *testing.T, r *Registry, id string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ := r.Get(id); len(job.Results) >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not record %d results", id, n)
+}
+
+// waitForState waits until the job reaches state
+func waitForState(t *testing.T, r *Registry, id string, state models.JobState) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ := r.Get(id); job.State == state {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach state %s", id, state)
+}
+
+// drain reads events until the channel is closed
+func drain(t *testing.T, events <-chan Event) []Event {
+	t.Helper()
+	var got []Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return got
+			}
+			got = append(got, ev)
+		case <-timeout:
+			t.Fatalf("stream not closed after %d events", len(got))
+		}
+	}
+}
+
+func TestSubscribeReplaysThenStreams(t *testing.T) {
+	runner := newFeedRunner()
+	r := NewRegistry(runner, 1)
+	job := r.Submit(models.CheckRequest{URL: "https://example.com/", Depth: 1}, crawler.DefaultCrawlOptions())
+
+	runner.feed <- status(0)
+	runner.feed <- status(1)
+	waitForResults(t, r, job.ID, 2)
+
+	replay, events, unsubscribe, err := r.Subscribe(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+	if len(replay) != 2 || replay[0].URL != status(0).URL || replay[1].URL != status(1).URL {
+		t.Errorf("replay = %v, want the first two results", replay)
+	}
+
+	runner.feed <- status(2)
+	runner.feed <- status(3)
+	close(runner.feed)
+
+	got := drain(t, events)
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 2 results and done", len(got))
+	}
+	for i, ev := range got[:2] {
+		if s, ok := ev.Data.(models.LinkStatus); ev.Name != EventResult || !ok || s.URL != status(i+2).URL {
+			t.Errorf("event %d = %s %v, want result %s", i, ev.Name, ev.Data, status(i+2).URL)
+		}
+	}
+	if done, ok := got[2].Data.(models.Job); got[2].Name != EventDone || !ok || done.State != models.JobDone || done.Results != nil {
+		t.Errorf("last event = %s %+v, want done without results", got[2].Name, got[2].Data)
+	}
+}
+
+func TestSubscribeAfterFinish(t *testing.T) {
+	runner := newFeedRunner()
+	r := NewRegistry(runner, 1)
+	job := r.Submit(models.CheckRequest{URL: "https://example.com/", Depth: 1}, crawler.DefaultCrawlOptions())
+	runner.feed <- status(0)
+	close(runner.feed)
+	waitForState(t, r, job.ID, models.JobDone)
+
+	replay, events, unsubscribe, err := r.Subscribe(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+	if len(replay) != 1 {
+		t.Errorf("replay has %d results, want 1", len(replay))
+	}
+	if got := drain(t, events); len(got) != 1 || got[0].Name != EventDone {
+		t.Errorf("events = %v, want only done", got)
+	}
+}
+
+func TestSubscribeUnknownJob(t *testing.T) {
+	r := NewRegistry(newFeedRunner(), 1)
+	if _, _, _, err := r.Subscribe("missing"); err != ErrNotFound {
+		t.Errorf("Subscribe() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	runner := newFeedRunner()
+	r := NewRegistry(runner, 1)
+	job := r.Submit(models.CheckRequest{URL: "https://example.com/", Depth: 1}, crawler.DefaultCrawlOptions())
+	defer close(runner.feed)
+
+	_, slow, unsubscribe, err := r.Subscribe(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+
+	// The crawl must not block on a subscriber that stopped reading
+	for i := 0; i <= subscriberBuffer; i++ {
+		runner.feed <- status(i)
+	}
+	waitForResults(t, r, job.ID, subscriberBuffer+1)
+
+	got := drain(t, slow)
+	if len(got) != subscriberBuffer {
+		t.Errorf("dropped subscriber got %d events, want the %d buffered", len(got), subscriberBuffer)
+	}
+	for _, ev := range got {
+		if ev.Name != EventResult {
+			t.Fatalf("dropped subscriber got a %s event", ev.Name)
+		}
+	}
+
+	// A dropped client resubscribes and replays everything it missed
+	replay, _, unsubscribeAgain, err := r.Subscribe(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribeAgain()
+	if len(replay) != subscriberBuffer+1 {
+		t.Errorf("replay has %d results, want %d", len(replay), subscriberBuffer+1)
+	}
+}
@@ -0,0 +1,285 @@
+// Package jobs runs crawls asynchronously and keeps track of their state
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+)
+
+// DefaultRetention is how long finished jobs are kept around
+const DefaultRetention = time.Hour
+
+var (
+	// ErrNotFound is returned when no job has the requested ID
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when cancelling a job that already finished
+	ErrFinished = errors.New("job already finished")
+)
+
+// Runner performs the actual crawl; *crawler.Crawler satisfies it
+type Runner interface {
+	CheckLinksWithOptions(ctx context.Context, baseURL string, maxDepth int, opts crawler.CrawlOptions) crawler.Result
+}
+
+// Registry queues crawl jobs, runs them on a bounded number of workers
+// and keeps their state until the retention period expires
+type Registry struct {
+	runner    Runner
+	slots     chan struct{}
+	retention time.Duration
+
+	// onFinish is called with every job whose crawl ran, once it ended
+	onFinish func(models.Job)
+
+	// running tracks the job goroutines so Close can wait for them
+	running sync.WaitGroup
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	closed bool
+}
+
+type job struct {
+	mu     sync.Mutex
+	info   models.Job
+	opts   crawler.CrawlOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+	subs   map[chan Event]struct{}
+}
+
+// NewRegistry creates a registry that runs at most workers crawls at once
+func NewRegistry(runner Runner, workers int) *Registry {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Registry{
+		runner:    runner,
+		slots:     make(chan struct{}, workers),
+		retention: DefaultRetention,
+		jobs:      make(map[string]*job),
+	}
+}
+
+// OnFinish registers a function called with every job whose crawl ran,
+// including failed and cancelled ones, once it ended. It must be set
+// before the first job is submitted.
+func (r *Registry) OnFinish(fn func(models.Job)) {
+	r.onFinish = fn
+}
+
+// Submit queues a new crawl job for the request and returns it immediately.
+// The progress and result callbacks in opts are replaced by the registry's.
+// Jobs submitted after Close are cancelled right away.
+func (r *Registry) Submit(req models.CheckRequest, opts crawler.CrawlOptions) models.Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		info: models.Job{
+			ID:        newID(),
+			URL:       req.URL,
+			Depth:     req.Depth,
+			Config:    &req,
+			State:     models.JobQueued,
+			CreatedAt: time.Now(),
+		},
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+		subs:   make(map[chan Event]struct{}),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		cancel()
+		now := time.Now()
+		j.info.State = models.JobCancelled
+		j.info.FinishedAt = &now
+		return j.snapshot()
+	}
+	r.prune()
+	r.jobs[j.info.ID] = j
+
+	r.running.Add(1)
+	go r.run(j)
+	return j.snapshot()
+}
+
+// Get returns the current state of a job
+func (r *Registry) Get(id string) (models.Job, error) {
+	r.mu.Lock()
+	j, ok := r.jobs[id]
+	r.mu.Unlock()
+	if !ok {
+		return models.Job{}, ErrNotFound
+	}
+	return j.snapshot(), nil
+}
+
+// Cancel stops a queued or running job. A running job keeps the results
+// streamed before it was cancelled until the crawl returns, and then its
+// aggregated partial results.
+func (r *Registry) Cancel(id string) (models.Job, error) {
+	r.mu.Lock()
+	j, ok := r.jobs[id]
+	r.mu.Unlock()
+	if !ok {
+		return models.Job{}, ErrNotFound
+	}
+
+	j.mu.Lock()
+	if j.info.State.Finished() {
+		j.mu.Unlock()
+		return j.snapshot(), ErrFinished
+	}
+	j.info.Truncated = j.info.State == models.JobRunning
+	j.info.State = models.JobCancelled
+	now := time.Now()
+	j.info.FinishedAt = &now
+	j.closeSubscribers()
+	j.mu.Unlock()
+
+	j.cancel()
+	return j.snapshot(), nil
+}
+
+// Close cancels every job that has not finished yet and waits until their
+// crawls returned and onFinish was called for them
+func (r *Registry) Close() {
+	r.mu.Lock()
+	r.closed = true
+	ids := make([]string, 0, len(r.jobs))
+	for id := range r.jobs {
+		ids = append(ids, id)
+	}
+	r.mu.Unlock()
+
+	for _, id := range ids {
+		r.Cancel(id)
+	}
+	r.running.Wait()
+}
+
+func (r *Registry) run(j *job) {
+	defer r.running.Done()
+
+	// Wait for a free worker unless the job gets cancelled first
+	select {
+	case r.slots <- struct{}{}:
+	case <-j.ctx.Done():
+		return
+	}
+	defer func() { <-r.slots }()
+
+	if !j.start() {
+		return
+	}
+	if r.onFinish != nil {
+		defer func() { r.onFinish(j.snapshot()) }()
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("Job %s panicked: %v", j.info.ID, rec)
+			j.fail(fmt.Errorf("crawl panicked: %v", rec))
+		}
+	}()
+
+	opts := j.opts
+	opts.OnProgress = j.setProgress
+	opts.OnResult = j.addResult
+
+	result := r.runner.CheckLinksWithOptions(j.ctx, j.info.URL, j.info.Depth, opts)
+	j.finish(result)
+}
+
+// prune drops finished jobs older than the retention period; r.mu must be held
+func (r *Registry) prune() {
+	cutoff := time.Now().Add(-r.retention)
+	for id, j := range r.jobs {
+		j.mu.Lock()
+		expired := j.info.FinishedAt != nil && j.info.FinishedAt.Before(cutoff)
+		j.mu.Unlock()
+		if expired {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+func (j *job) snapshot() models.Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	info := j.info
+	// The counts keep changing while the job runs
+	info.ErrorCounts = maps.Clone(info.ErrorCounts)
+	return info
+}
+
+func (j *job) start() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.info.State != models.JobQueued {
+		return false
+	}
+	now := time.Now()
+	j.info.State = models.JobRunning
+	j.info.StartedAt = &now
+	return true
+}
+
+func (j *job) setProgress(p models.CrawlProgress) {
+	j.mu.Lock()
+	j.info.Progress = p
+	j.mu.Unlock()
+}
+
+func (j *job) finish(result crawler.Result) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch j.info.State {
+	case models.JobRunning:
+		now := time.Now()
+		j.info.State = models.JobDone
+		j.info.Truncated = result.Truncated
+		j.info.FinishedAt = &now
+		j.closeSubscribers()
+	case models.JobCancelled:
+		// A cancelled job keeps its state, but the partial results the
+		// crawl returns carry the sources and referrers streamed ones lack
+	default:
+		return
+	}
+	j.info.Results = result.Links
+	j.info.ErrorCounts = crawler.CountCategories(result.Links)
+}
+
+func (j *job) fail(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.info.State != models.JobRunning {
+		return
+	}
+	now := time.Now()
+	j.info.State = models.JobFailed
+	j.info.Error = err.Error()
+	j.info.FinishedAt = &now
+	j.closeSubscribers()
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
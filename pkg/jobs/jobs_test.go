@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+)
+
+// blockingRunner crawls until it is cancelled and then takes a while to
+// return, like a crawl waiting for requests in flight
+type blockingRunner struct {
+	started chan struct{}
+}
+
+func (r blockingRunner) CheckLinksWithOptions(ctx context.Context, baseURL string, maxDepth int, opts crawler.CrawlOptions) crawler.Result {
+	r.started <- struct{}{}
+	<-ctx.Done()
+	time.Sleep(50 * time.Millisecond)
+	return crawler.Result{Truncated: true}
+}
+
+func TestCloseWaitsForRunningJobs(t *testing.T) {
+	runner := blockingRunner{started: make(chan struct{}, 2)}
+	r := NewRegistry(runner, 2)
+	var finished atomic.Int32
+	r.OnFinish(func(job models.Job) {
+		// Stands in for saving the run, which must happen before the
+		// stores are closed
+		time.Sleep(10 * time.Millisecond)
+		finished.Add(1)
+	})
+
+	r.Submit(models.CheckRequest{URL: "https://a.example"}, crawler.DefaultCrawlOptions())
+	r.Submit(models.CheckRequest{URL: "https://b.example"}, crawler.DefaultCrawlOptions())
+	<-runner.started
+	<-runner.started
+
+	r.Close()
+	if n := finished.Load(); n != 2 {
+		t.Fatalf("Close returned after %d of 2 jobs finished", n)
+	}
+}
+
+func TestSubmitAfterCloseIsCancelled(t *testing.T) {
+	runner := blockingRunner{started: make(chan struct{}, 1)}
+	r := NewRegistry(runner, 1)
+	r.Close()
+
+	job := r.Submit(models.CheckRequest{URL: "https://a.example"}, crawler.DefaultCrawlOptions())
+	if job.State != models.JobCancelled {
+		t.Fatalf("job submitted after Close is %s, want cancelled", job.State)
+	}
+	select {
+	case <-runner.started:
+		t.Fatal("job submitted after Close was run")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestCancelKeepsPartialResults(t *testing.T) {
+	runner := newFeedRunner()
+	r := NewRegistry(runner, 1)
+	job := r.Submit(models.CheckRequest{URL: "https://example.com/", Depth: 1}, crawler.DefaultCrawlOptions())
+	runner.feed <- status(0)
+	waitForResults(t, r, job.ID, 1)
+
+	cancelled, err := r.Cancel(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.State != models.JobCancelled || !cancelled.Truncated || len(cancelled.Results) != 1 {
+		t.Errorf("Cancel() = %s, truncated %v, %d results; want cancelled, truncated, 1 result",
+			cancelled.State, cancelled.Truncated, len(cancelled.Results))
+	}
+	if _, err := r.Cancel(job.ID); err != ErrFinished {
+		t.Errorf("second Cancel() error = %v, want ErrFinished", err)
+	}
+
+	// Whatever the crawl returns after being cancelled does not revive the job
+	time.Sleep(10 * time.Millisecond)
+	got, _ := r.Get(job.ID)
+	if got.State != models.JobCancelled || len(got.Results) != 1 {
+		t.Errorf("Get() = %s with %d results, want cancelled with 1", got.State, len(got.Results))
+	}
+}
+
+func TestCancelQueuedJob(t *testing.T) {
+	runner := newFeedRunner()
+	r := NewRegistry(runner, 1)
+	running := r.Submit(models.CheckRequest{URL: "https://a.example/", Depth: 1}, crawler.DefaultCrawlOptions())
+	defer r.Cancel(running.ID)
+	waitForState(t, r, running.ID, models.JobRunning)
+	queued := r.Submit(models.CheckRequest{URL: "https://b.example/", Depth: 1}, crawler.DefaultCrawlOptions())
+
+	cancelled, err := r.Cancel(queued.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.State != models.JobCancelled || cancelled.Truncated {
+		t.Errorf("Cancel() = %s, truncated %v; want cancelled, not truncated", cancelled.State, cancelled.Truncated)
+	}
+}
+
+// aggregatingRunner streams one result and, once cancelled, returns it
+// with the referrers a crawl only adds when it winds down
+type aggregatingRunner struct{}
+
+func (aggregatingRunner) CheckLinksWithOptions(ctx context.Context, baseURL string, maxDepth int, opts crawler.CrawlOptions) crawler.Result {
+	link := models.LinkStatus{URL: "https://example.com/missing", StatusCode: 404, ErrorCategory: models.ErrorHTTPClient}
+	opts.OnResult(link)
+	<-ctx.Done()
+	link.Referrers = []string{"https://example.com/"}
+	link.Sources = []models.LinkSource{{ParentURL: "https://example.com/"}}
+	return crawler.Result{Links: []models.LinkStatus{link}, Truncated: true}
+}
+
+func TestCancelledJobKeepsAggregatedResults(t *testing.T) {
+	r := NewRegistry(aggregatingRunner{}, 1)
+	finished := make(chan models.Job, 1)
+	r.OnFinish(func(job models.Job) { finished <- job })
+
+	job := r.Submit(models.CheckRequest{URL: "https://example.com/", Depth: 1}, crawler.DefaultCrawlOptions())
+	waitForResults(t, r, job.ID, 1)
+	if _, err := r.Cancel(job.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved models.Job
+	select {
+	case saved = <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled job never finished")
+	}
+	got, _ := r.Get(job.ID)
+	for name, j := range map[string]models.Job{"OnFinish": saved, "Get": got} {
+		if j.State != models.JobCancelled || !j.Truncated {
+			t.Errorf("%s: %s, truncated %v; want cancelled and truncated", name, j.State, j.Truncated)
+		}
+		if len(j.Results) != 1 || len(j.Results[0].Referrers) != 1 || len(j.Results[0].Sources) != 1 {
+			t.Errorf("%s: results = %+v, want the aggregated partial result", name, j.Results)
+		}
+		if j.ErrorCounts[models.ErrorHTTPClient] != 1 {
+			t.Errorf("%s: error counts = %v, want 1 %s", name, j.ErrorCounts, models.ErrorHTTPClient)
+		}
+	}
+}
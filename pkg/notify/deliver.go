@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-BLT-Event"
+	HeaderDelivery  = "X-BLT-Delivery"
+	HeaderSignature = "X-BLT-Signature-256"
+)
+
+// Sign returns the signature header value of a payload: "sha256=" followed
+// by the hex HMAC-SHA256 of body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver posts body to a notifier until it is accepted, the retry policy
+// gives up or the dispatcher is closed, recording every attempt
+func (d *Dispatcher) deliver(n models.Notifier, delivery models.Delivery, body []byte) {
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		code, retryAfter, err := d.post(n, delivery.ID, body)
+
+		record := models.Attempt{StatusCode: code, Duration: time.Since(start).String()}
+		retry := false
+		switch {
+		case err != nil:
+			record.Error = err.Error()
+			record.ErrorCategory = crawler.ClassifyError(err)
+			retry = d.retry.RetryableError(err)
+		case code < 200 || code >= 300:
+			record.Error = fmt.Sprintf("unexpected status %d", code)
+			record.ErrorCategory = crawler.ClassifyStatus(code)
+			retry = d.retry.RetryableStatus(code)
+		}
+		delivery.StatusCode = code
+		delivery.Error = record.Error
+
+		if record.Error == "" {
+			now := time.Now()
+			delivery.State = models.DeliveryDelivered
+			delivery.DeliveredAt = &now
+			delivery.Attempts = append(delivery.Attempts, record)
+			d.update(delivery)
+			log.Printf("Delivered notification %s of run %s to notifier %s", delivery.ID, delivery.RunID, n.ID)
+			return
+		}
+
+		if !retry || attempt >= d.retry.MaxAttempts {
+			delivery.State = models.DeliveryFailed
+			delivery.Attempts = append(delivery.Attempts, record)
+			d.update(delivery)
+			log.Printf("Failed to deliver notification %s to notifier %s: %s", delivery.ID, n.ID, delivery.Error)
+			return
+		}
+
+		delay := d.retry.Backoff(attempt, retryAfter)
+		record.Delay = delay.String()
+		delivery.Attempts = append(delivery.Attempts, record)
+		d.update(delivery)
+		log.Printf("Retrying notification %s to notifier %s in %s (attempt %d/%d): %s", delivery.ID, n.ID, delay, attempt, d.retry.MaxAttempts, delivery.Error)
+
+		select {
+		case <-time.After(delay):
+		case <-d.ctx.Done():
+			delivery.State = models.DeliveryFailed
+			delivery.Error = "abandoned on shutdown"
+			d.update(delivery)
+			return
+		}
+	}
+}
+
+// post sends one delivery attempt and returns the response status and
+// the delay requested by a Retry-After header
+func (d *Dispatcher) post(n models.Notifier, id string, body []byte) (int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", crawler.RobotsUserAgent)
+	req.Header.Set(HeaderEvent, EventRunFinished)
+	req.Header.Set(HeaderDelivery, id)
+	if n.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(n.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	return resp.StatusCode, crawler.ParseRetryAfter(resp.Header.Get("Retry-After")), nil
+}
@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/export"
+)
+
+// maxChatLinks caps the links listed in chat messages, which are read by people
+const maxChatLinks = 10
+
+// formatter renders a notification as the request body of a notifier
+type formatter func(models.Notification) ([]byte, error)
+
+var formatters = map[models.NotifierFormat]formatter{
+	models.FormatWebhook: formatWebhook,
+	models.FormatSlack:   formatSlack,
+	models.FormatTeams:   formatTeams,
+}
+
+func formatWebhook(n models.Notification) ([]byte, error) {
+	return json.Marshal(n)
+}
+
+// headline sums up a notification in one sentence
+func headline(n models.Notification) string {
+	run := n.Run
+	switch {
+	case run.State == models.JobFailed:
+		return fmt.Sprintf("Crawl of %s failed: %s", run.URL, run.Error)
+	case run.State == models.JobCancelled:
+		return fmt.Sprintf("Crawl of %s was cancelled", run.URL)
+	}
+
+	text := fmt.Sprintf("%d broken %s on %s", run.Summary.Broken, plural(run.Summary.Broken, "link", "links"), run.URL)
+	if run.Summary.Broken == 0 {
+		text = "No broken links on " + run.URL
+	}
+	if newly := n.Changes[models.ChangeNewlyBroken]; newly > 0 {
+		text += fmt.Sprintf(", %d newly broken", newly)
+	}
+	if fixed := n.Changes[models.ChangeFixed]; fixed > 0 {
+		text += fmt.Sprintf(", %d fixed", fixed)
+	}
+	if run.Truncated {
+		text += " (crawl stopped early)"
+	}
+	return text
+}
+
+// chatLinks returns the links worth listing in a chat message: the newly
+// broken ones when the run was compared to a previous one, otherwise
+// every broken link. more is the number left out.
+func chatLinks(n models.Notification) (links []models.LinkStatus, more int) {
+	links, total := n.Broken, n.Run.Summary.Broken
+	if n.Previous != "" {
+		links, total = n.NewlyBroken, n.Changes[models.ChangeNewlyBroken]
+	}
+	if len(links) > maxChatLinks {
+		links = links[:maxChatLinks]
+	}
+	return links, total - len(links)
+}
+
+// chatTitle is the heading of the list returned by chatLinks
+func chatTitle(n models.Notification) string {
+	if n.Previous != "" {
+		return "Newly broken"
+	}
+	return "Broken"
+}
+
+// facts are the run counters shown in chat messages
+func facts(n models.Notification) [][2]string {
+	s := n.Run.Summary
+	return [][2]string{
+		{"Checked", fmt.Sprint(s.Total)},
+		{"Working", fmt.Sprint(s.Working)},
+		{"Broken", fmt.Sprint(s.Broken)},
+		{"Skipped", fmt.Sprint(s.Skipped)},
+	}
+}
+
+// footer names the run and the run it was compared to
+func footer(n models.Notification) string {
+	if n.Previous == "" {
+		return "Run " + n.Run.ID
+	}
+	return fmt.Sprintf("Run %s compared to run %s", n.Run.ID, n.Previous)
+}
+
+// linkText describes a broken link in a chat message
+func linkText(l models.LinkStatus) string {
+	if l.ParentURL == "" {
+		return export.Problem(l)
+	}
+	return fmt.Sprintf("%s, found on %s", export.Problem(l), l.ParentURL)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,314 @@
+// Package notify tells webhooks and chat channels about finished crawl runs
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+)
+
+const (
+	// EventRunFinished is the event of every notification
+	EventRunFinished = "run.finished"
+	// maxLinks caps the broken links listed in a notification
+	maxLinks = 50
+	// maxDeliveries is how many deliveries the delivery log keeps
+	maxDeliveries = 500
+	// deliveryTimeout bounds a single delivery attempt
+	deliveryTimeout = 10 * time.Second
+)
+
+// ErrNotFound is returned when no notifier has the requested ID
+var ErrNotFound = errors.New("notifier not found")
+
+// PreviousFunc returns the run the changes of run are counted against, with
+// its results: the last complete run of the same URL, if there is one
+type PreviousFunc func(run models.Run) (models.Run, bool)
+
+// Dispatcher keeps notifiers and sends them the runs their rules fire on.
+// Deliveries are sent in the background and retried on network errors,
+// 429 and 5xx responses; the most recent ones are kept in a delivery log.
+type Dispatcher struct {
+	client *http.Client
+	retry  crawler.RetryPolicy
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu         sync.Mutex
+	notifiers  map[string]models.Notifier
+	deliveries []models.Delivery // Oldest first
+}
+
+// DefaultRetryPolicy returns the retry policy of deliveries
+func DefaultRetryPolicy() crawler.RetryPolicy {
+	return crawler.RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   5 * time.Second,
+		MaxDelay:    2 * time.Minute,
+		Jitter:      0.2,
+		RetryStatuses: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// New creates a dispatcher sending deliveries through client, retrying
+// them with the given policy. A nil client uses one with a 10s timeout.
+func New(client *http.Client, retry crawler.RetryPolicy) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: deliveryTimeout}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		client:    client,
+		retry:     retry,
+		ctx:       ctx,
+		cancel:    cancel,
+		notifiers: make(map[string]models.Notifier),
+	}
+}
+
+// Close abandons pending retries and waits for deliveries in flight;
+// runs notified afterwards are logged as failed deliveries
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	d.cancel()
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// Create adds a notifier
+func (d *Dispatcher) Create(req models.NotifierRequest) models.Notifier {
+	n := models.Notifier{
+		ID:              newID(),
+		NotifierRequest: withDefaults(req),
+		CreatedAt:       time.Now(),
+	}
+	d.mu.Lock()
+	d.notifiers[n.ID] = n
+	d.mu.Unlock()
+	return public(n)
+}
+
+// Update replaces a notifier. An empty secret keeps the current one, so
+// clients can change other settings without knowing it.
+func (d *Dispatcher) Update(id string, req models.NotifierRequest) (models.Notifier, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n, ok := d.notifiers[id]
+	if !ok {
+		return models.Notifier{}, ErrNotFound
+	}
+	secret := n.Secret
+	n.NotifierRequest = withDefaults(req)
+	if n.Secret == "" {
+		n.Secret = secret
+	}
+	d.notifiers[id] = n
+	return public(n), nil
+}
+
+// Get returns a notifier
+func (d *Dispatcher) Get(id string) (models.Notifier, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n, ok := d.notifiers[id]
+	if !ok {
+		return models.Notifier{}, ErrNotFound
+	}
+	return public(n), nil
+}
+
+// List returns every notifier, oldest first
+func (d *Dispatcher) List() []models.Notifier {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	notifiers := make([]models.Notifier, 0, len(d.notifiers))
+	for _, n := range d.notifiers {
+		notifiers = append(notifiers, public(n))
+	}
+	slices.SortFunc(notifiers, func(a, b models.Notifier) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return notifiers
+}
+
+// Delete removes a notifier; deliveries already started are still sent
+func (d *Dispatcher) Delete(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.notifiers[id]; !ok {
+		return ErrNotFound
+	}
+	delete(d.notifiers, id)
+	return nil
+}
+
+// Deliveries returns the logged deliveries, most recent first; a non-empty
+// notifierID only returns those of one notifier
+func (d *Dispatcher) Deliveries(notifierID string) []models.Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	deliveries := []models.Delivery{}
+	for i := len(d.deliveries) - 1; i >= 0; i-- {
+		if notifierID == "" || d.deliveries[i].NotifierID == notifierID {
+			deliveries = append(deliveries, d.deliveries[i])
+		}
+	}
+	return deliveries
+}
+
+// Notify sends a finished run to every notifier whose rule fires on it.
+// previous is only called when a notifier watches the run's URL.
+func (d *Dispatcher) Notify(run models.Run, previous PreviousFunc) {
+	d.mu.Lock()
+	var targets []models.Notifier
+	for _, n := range d.notifiers {
+		if !n.Paused && (len(n.Sites) == 0 || slices.Contains(n.Sites, run.URL)) {
+			targets = append(targets, n)
+		}
+	}
+	d.mu.Unlock()
+	if len(targets) == 0 {
+		return
+	}
+
+	notification := newNotification(run)
+	if prev, ok := previous(run); ok {
+		diff := crawler.Diff(prev.Results, run.Results, crawler.DefaultDiffOptions())
+		notification.Previous = prev.ID
+		notification.Changes = diff.Counts
+		for _, change := range diff.Changes {
+			if change.Change == models.ChangeNewlyBroken && len(notification.NewlyBroken) < maxLinks {
+				notification.NewlyBroken = append(notification.NewlyBroken, *change.After)
+			}
+		}
+	}
+
+	for _, n := range targets {
+		if !fires(n.Rule, notification) {
+			continue
+		}
+		body, err := formatters[n.Format](notification)
+		if err != nil {
+			log.Printf("Failed to format notification for notifier %s: %v", n.ID, err)
+			continue
+		}
+		d.send(n, run, body)
+	}
+}
+
+// fires reports whether a rule fires on a notification
+func fires(rule models.NotifyRule, n models.Notification) bool {
+	switch rule {
+	case models.NotifyBroken:
+		return n.Run.Summary.Broken > 0
+	case models.NotifyRegression:
+		return n.Changes[models.ChangeNewlyBroken] > 0
+	}
+	return true
+}
+
+// newNotification builds the notification of a run, leaving out its results
+func newNotification(run models.Run) models.Notification {
+	n := models.Notification{Event: EventRunFinished, Run: run}
+	n.Run.Results = nil
+	for _, link := range run.Results {
+		if len(n.Broken) == maxLinks {
+			break
+		}
+		if !link.IsWorking && !link.Skipped {
+			n.Broken = append(n.Broken, link)
+		}
+	}
+	return n
+}
+
+// send logs a pending delivery and delivers it in the background
+func (d *Dispatcher) send(n models.Notifier, run models.Run, body []byte) {
+	delivery := models.Delivery{
+		ID:         newID(),
+		NotifierID: n.ID,
+		RunID:      run.ID,
+		URL:        run.URL,
+		Rule:       n.Rule,
+		State:      models.DeliveryPending,
+		CreatedAt:  time.Now(),
+	}
+	d.mu.Lock()
+	closed := d.ctx.Err() != nil
+	if closed {
+		delivery.State = models.DeliveryFailed
+		delivery.Error = "notifier closed"
+	} else {
+		d.wg.Add(1)
+	}
+	d.deliveries = append(d.deliveries, delivery)
+	if len(d.deliveries) > maxDeliveries {
+		d.deliveries = slices.Delete(d.deliveries, 0, len(d.deliveries)-maxDeliveries)
+	}
+	d.mu.Unlock()
+	if closed {
+		return
+	}
+
+	go func() {
+		defer d.wg.Done()
+		d.deliver(n, delivery, body)
+	}()
+}
+
+// update replaces a logged delivery, unless it was already dropped from the log
+func (d *Dispatcher) update(delivery models.Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range d.deliveries {
+		if d.deliveries[i].ID == delivery.ID {
+			d.deliveries[i] = delivery
+			return
+		}
+	}
+}
+
+// withDefaults fills in the default format and rule
+func withDefaults(req models.NotifierRequest) models.NotifierRequest {
+	if req.Format == "" {
+		req.Format = models.FormatWebhook
+	}
+	if req.Rule == "" {
+		req.Rule = models.NotifyAlways
+	}
+	return req
+}
+
+// public hides the secret of a notifier
+func public(n models.Notifier) models.Notifier {
+	n.Signed = n.Secret != ""
+	n.Secret = ""
+	return n
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
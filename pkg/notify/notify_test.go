@@ -0,0 +1,331 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// receiver is a local webhook endpoint answering with the queued status
+// codes, then 200, and recording every request
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+		if len(r.statuses) > 0 {
+			w.WriteHeader(r.statuses[0])
+			r.statuses = r.statuses[1:]
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+// newTestDispatcher retries quickly so tests don't wait for real backoff
+func newTestDispatcher(t *testing.T) *Dispatcher {
+	t.Helper()
+	retry := DefaultRetryPolicy()
+	retry.BaseDelay = time.Millisecond
+	retry.Jitter = 0
+	d := New(nil, retry)
+	t.Cleanup(d.Close)
+	return d
+}
+
+// waitForDeliveries waits until the notifier has n deliveries that are no
+// longer pending and returns them, most recent first
+func waitForDeliveries(t *testing.T, d *Dispatcher, notifierID string, n int) []models.Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries := d.Deliveries(notifierID)
+		done := 0
+		for _, delivery := range deliveries {
+			if delivery.State != models.DeliveryPending {
+				done++
+			}
+		}
+		if done >= n {
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d finished deliveries, want %d: %+v", done, n, deliveries)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+var (
+	working = models.LinkStatus{URL: "https://example.com/", StatusCode: 200, IsWorking: true}
+	broken  = models.LinkStatus{URL: "https://example.com/gone", StatusCode: 404, ParentURL: "https://example.com/"}
+)
+
+func newRun(id string, links ...models.LinkStatus) models.Run {
+	return models.Run{
+		ID:      id,
+		URL:     "https://example.com",
+		State:   models.JobDone,
+		Summary: models.Summarize(links),
+		Results: links,
+	}
+}
+
+func noPrevious(models.Run) (models.Run, bool) { return models.Run{}, false }
+
+func previous(run models.Run) PreviousFunc {
+	return func(models.Run) (models.Run, bool) { return run, true }
+}
+
+func TestDeliverySignature(t *testing.T) {
+	r := newReceiver(t)
+	d := newTestDispatcher(t)
+	signed := d.Create(models.NotifierRequest{URL: r.URL, Secret: "s3cret"})
+	if !signed.Signed || signed.Secret != "" {
+		t.Fatalf("created notifier exposes its secret or is unsigned: %+v", signed)
+	}
+
+	d.Notify(newRun("run-1", working, broken), noPrevious)
+	waitForDeliveries(t, d, signed.ID, 1)
+
+	req := r.received()[0]
+	if got, want := req.header.Get(HeaderSignature), Sign("s3cret", req.body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if Sign("other", req.body) == req.header.Get(HeaderSignature) {
+		t.Error("signature does not depend on the secret")
+	}
+	if got := req.header.Get(HeaderEvent); got != EventRunFinished {
+		t.Errorf("event header = %q, want %q", got, EventRunFinished)
+	}
+	if req.header.Get(HeaderDelivery) == "" {
+		t.Error("delivery header is missing")
+	}
+
+	var n models.Notification
+	if err := json.Unmarshal(req.body, &n); err != nil {
+		t.Fatalf("webhook body is not a notification: %v", err)
+	}
+	if n.Run.ID != "run-1" || n.Run.Results != nil || len(n.Broken) != 1 {
+		t.Errorf("unexpected notification %+v", n)
+	}
+}
+
+func TestUnsignedDeliveryHasNoSignature(t *testing.T) {
+	r := newReceiver(t)
+	d := newTestDispatcher(t)
+	n := d.Create(models.NotifierRequest{URL: r.URL})
+	d.Notify(newRun("run-1", working), noPrevious)
+	waitForDeliveries(t, d, n.ID, 1)
+	if got := r.received()[0].header.Get(HeaderSignature); got != "" {
+		t.Errorf("unsigned notifier sent signature %q", got)
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantState string
+		wantTries int
+	}{
+		{"accepted", nil, models.DeliveryDelivered, 1},
+		{"5xx then accepted", []int{503, 500}, models.DeliveryDelivered, 3},
+		{"429 then accepted", []int{429}, models.DeliveryDelivered, 2},
+		{"5xx until attempts run out", []int{502, 502, 502, 502, 502}, models.DeliveryFailed, 4},
+		{"4xx is not retried", []int{410}, models.DeliveryFailed, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReceiver(t, tt.statuses...)
+			d := newTestDispatcher(t)
+			n := d.Create(models.NotifierRequest{URL: r.URL})
+			d.Notify(newRun("run-1", working), noPrevious)
+
+			delivery := waitForDeliveries(t, d, n.ID, 1)[0]
+			if delivery.State != tt.wantState {
+				t.Errorf("state = %s, want %s", delivery.State, tt.wantState)
+			}
+			if len(delivery.Attempts) != tt.wantTries || len(r.received()) != tt.wantTries {
+				t.Errorf("got %d attempts and %d requests, want %d", len(delivery.Attempts), len(r.received()), tt.wantTries)
+			}
+			if tt.wantState == models.DeliveryDelivered && (delivery.DeliveredAt == nil || delivery.Error != "") {
+				t.Errorf("delivered delivery not marked as such: %+v", delivery)
+			}
+			if tt.wantState == models.DeliveryFailed && delivery.Error == "" {
+				t.Error("failed delivery has no error")
+			}
+			for _, a := range delivery.Attempts[:len(delivery.Attempts)-1] {
+				if a.Delay == "" {
+					t.Errorf("retried attempt without delay: %+v", a)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliveryLog(t *testing.T) {
+	r := newReceiver(t)
+	d := newTestDispatcher(t)
+	a := d.Create(models.NotifierRequest{URL: r.URL})
+	b := d.Create(models.NotifierRequest{URL: r.URL, Rule: models.NotifyBroken})
+
+	d.Notify(newRun("run-1", working), noPrevious)
+	waitForDeliveries(t, d, a.ID, 1)
+	d.Notify(newRun("run-2", working, broken), noPrevious)
+	waitForDeliveries(t, d, a.ID, 2)
+	waitForDeliveries(t, d, b.ID, 1)
+
+	got := d.Deliveries(a.ID)
+	if len(got) != 2 || got[0].RunID != "run-2" || got[1].RunID != "run-1" {
+		t.Fatalf("deliveries of a = %+v, want run-2 then run-1", got)
+	}
+	for _, delivery := range got {
+		if delivery.NotifierID != a.ID {
+			t.Errorf("delivery %s belongs to notifier %s", delivery.ID, delivery.NotifierID)
+		}
+	}
+	if all := d.Deliveries(""); len(all) != 3 {
+		t.Errorf("got %d deliveries in total, want 3", len(all))
+	}
+}
+
+func TestRules(t *testing.T) {
+	before := newRun("run-1", working)
+	alreadyBroken := newRun("run-1", working, broken)
+	tests := []struct {
+		name     string
+		rule     models.NotifyRule
+		run      models.Run
+		previous PreviousFunc
+		want     bool
+	}{
+		{"always without broken links", models.NotifyAlways, newRun("run-2", working), noPrevious, true},
+		{"broken with broken links", models.NotifyBroken, newRun("run-2", working, broken), noPrevious, true},
+		{"broken without broken links", models.NotifyBroken, newRun("run-2", working), noPrevious, false},
+		{"regression on newly broken link", models.NotifyRegression, newRun("run-2", working, broken), previous(before), true},
+		{"regression on still broken link", models.NotifyRegression, newRun("run-2", working, broken), previous(alreadyBroken), false},
+		{"regression without previous run", models.NotifyRegression, newRun("run-2", working, broken), noPrevious, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReceiver(t)
+			d := newTestDispatcher(t)
+			n := d.Create(models.NotifierRequest{URL: r.URL, Rule: tt.rule})
+			d.Notify(tt.run, tt.previous)
+			// A delivery is logged synchronously when the rule fires
+			if got := len(d.Deliveries(n.ID)) == 1; got != tt.want {
+				t.Errorf("fired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifierFilters(t *testing.T) {
+	r := newReceiver(t)
+	d := newTestDispatcher(t)
+	other := d.Create(models.NotifierRequest{URL: r.URL, Sites: []string{"https://other.example"}})
+	paused := d.Create(models.NotifierRequest{URL: r.URL, Paused: true})
+	site := d.Create(models.NotifierRequest{URL: r.URL, Sites: []string{"https://example.com"}})
+
+	d.Notify(newRun("run-1", working), noPrevious)
+	if n := len(d.Deliveries(other.ID)); n != 0 {
+		t.Errorf("notifier of another site got %d deliveries", n)
+	}
+	if n := len(d.Deliveries(paused.ID)); n != 0 {
+		t.Errorf("paused notifier got %d deliveries", n)
+	}
+	if n := len(d.Deliveries(site.ID)); n != 1 {
+		t.Errorf("notifier of the site got %d deliveries, want 1", n)
+	}
+}
+
+func TestUpdateKeepsSecret(t *testing.T) {
+	r := newReceiver(t)
+	d := newTestDispatcher(t)
+	n := d.Create(models.NotifierRequest{URL: r.URL, Secret: "s3cret"})
+	if _, err := d.Update(n.ID, models.NotifierRequest{URL: r.URL, Format: models.FormatSlack}); err != nil {
+		t.Fatal(err)
+	}
+	d.Notify(newRun("run-1", working), noPrevious)
+	waitForDeliveries(t, d, n.ID, 1)
+	req := r.received()[0]
+	if got, want := req.header.Get(HeaderSignature), Sign("s3cret", req.body); got != want {
+		t.Errorf("signature after update = %q, want %q", got, want)
+	}
+}
+
+func TestChatFormats(t *testing.T) {
+	notification := newNotification(newRun("run-2", working, broken))
+	notification.Previous = "run-1"
+	notification.Changes = map[models.LinkChangeKind]int{models.ChangeNewlyBroken: 1}
+	notification.NewlyBroken = []models.LinkStatus{broken}
+
+	for _, format := range []models.NotifierFormat{models.FormatSlack, models.FormatTeams} {
+		t.Run(string(format), func(t *testing.T) {
+			body, err := formatters[format](notification)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !json.Valid(body) {
+				t.Fatalf("invalid JSON: %s", body)
+			}
+			for _, want := range []string{
+				"1 broken link on https://example.com, 1 newly broken",
+				"https://example.com/gone",
+				"HTTP 404, found on https://example.com/",
+				"Run run-2 compared to run run-1",
+			} {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("payload lacks %q: %s", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestSlackEscapesMarkup(t *testing.T) {
+	link := broken
+	link.URL = "https://example.com/a<b>&c"
+	body, err := formatSlack(newNotification(newRun("run-1", link)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg slackMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.Fatal(err)
+	}
+	list := msg.Blocks[2].Text.Text
+	if !strings.Contains(list, "<https://example.com/a&lt;b&gt;&amp;c>") {
+		t.Errorf("link is not escaped: %s", list)
+	}
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// slackMessage is a Slack incoming webhook message. Text is shown in
+// notifications and by clients that don't render blocks.
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackEscaper escapes the characters Slack reserves for its markup
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func formatSlack(n models.Notification) ([]byte, error) {
+	text := headline(n)
+	msg := slackMessage{
+		Text: text,
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*" + slackEscaper.Replace(text) + "*"}},
+		},
+	}
+
+	fields := slackBlock{Type: "section"}
+	for _, f := range facts(n) {
+		fields.Fields = append(fields.Fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", f[0], f[1])})
+	}
+	msg.Blocks = append(msg.Blocks, fields)
+
+	if links, more := chatLinks(n); len(links) > 0 {
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%s*\n", chatTitle(n))
+		for _, l := range links {
+			fmt.Fprintf(&b, "• <%s> %s\n", slackEscaper.Replace(l.URL), slackEscaper.Replace(linkText(l)))
+		}
+		if more > 0 {
+			fmt.Fprintf(&b, "…and %d more\n", more)
+		}
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: b.String()}})
+	}
+
+	msg.Blocks = append(msg.Blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: footer(n)}}})
+
+	return json.Marshal(msg)
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+const (
+	adaptiveCardType    = "application/vnd.microsoft.card.adaptive"
+	adaptiveCardSchema  = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardVersion = "1.4"
+)
+
+// teamsMessage carries an adaptive card, which both Teams incoming
+// webhooks and Workflows webhooks accept
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     adaptiveCard `json:"content"`
+}
+
+type adaptiveCard struct {
+	Schema  string        `json:"$schema"`
+	Type    string        `json:"type"`
+	Version string        `json:"version"`
+	Body    []cardElement `json:"body"`
+}
+
+// cardElement is a TextBlock or a FactSet
+type cardElement struct {
+	Type     string     `json:"type"`
+	Text     string     `json:"text,omitempty"`
+	Weight   string     `json:"weight,omitempty"`
+	Size     string     `json:"size,omitempty"`
+	Wrap     bool       `json:"wrap,omitempty"`
+	IsSubtle bool       `json:"isSubtle,omitempty"`
+	Facts    []cardFact `json:"facts,omitempty"`
+}
+
+type cardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+func formatTeams(n models.Notification) ([]byte, error) {
+	body := []cardElement{
+		{Type: "TextBlock", Text: headline(n), Weight: "Bolder", Size: "Medium", Wrap: true},
+	}
+
+	set := cardElement{Type: "FactSet"}
+	for _, f := range facts(n) {
+		set.Facts = append(set.Facts, cardFact{Title: f[0], Value: f[1]})
+	}
+	body = append(body, set)
+
+	if links, more := chatLinks(n); len(links) > 0 {
+		body = append(body, cardElement{Type: "TextBlock", Text: chatTitle(n), Weight: "Bolder", Wrap: true})
+		for _, l := range links {
+			body = append(body, cardElement{Type: "TextBlock", Text: fmt.Sprintf("- [%s](%s) %s", l.URL, l.URL, linkText(l)), Wrap: true})
+		}
+		if more > 0 {
+			body = append(body, cardElement{Type: "TextBlock", Text: fmt.Sprintf("…and %d more", more), Wrap: true})
+		}
+	}
+
+	body = append(body, cardElement{Type: "TextBlock", Text: footer(n), IsSubtle: true, Wrap: true})
+
+	return json.Marshal(teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: adaptiveCardType,
+			Content: adaptiveCard{
+				Schema:  adaptiveCardSchema,
+				Type:    "AdaptiveCard",
+				Version: adaptiveCardVersion,
+				Body:    body,
+			},
+		}},
+	})
+}
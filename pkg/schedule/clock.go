@@ -0,0 +1,16 @@
+package schedule
+
+import "time"
+
+// Clock tells the scheduler the time; tests swap in a clock they advance by hand
+type Clock interface {
+	Now() time.Time
+	// After delivers the current time once d has passed
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the wall clock
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
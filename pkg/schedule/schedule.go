@@ -0,0 +1,364 @@
+// Package schedule starts crawl jobs on recurring schedules
+package schedule
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/aocamilo/broken-links-tester/pkg/jobs"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// MinInterval is the shortest interval a schedule may repeat at
+	MinInterval = time.Minute
+	// maxSleep bounds how long the worker sleeps, so it notices clock jumps
+	maxSleep = time.Minute
+)
+
+// ErrNotFound is returned when no schedule has the requested ID
+var ErrNotFound = errors.New("schedule not found")
+
+// Launcher starts crawl jobs and reports on them; *jobs.Registry satisfies it.
+// Get must return jobs.ErrNotFound for jobs it no longer keeps.
+type Launcher interface {
+	Submit(req models.CheckRequest, opts crawler.CrawlOptions) models.Job
+	Get(id string) (models.Job, error)
+}
+
+// OptionsFunc builds the crawl options of a request, rejecting invalid ones
+type OptionsFunc func(models.CheckRequest) (crawler.CrawlOptions, error)
+
+// Scheduler keeps recurring crawls and starts a job whenever one is due.
+// A run is skipped while the previous job of the same schedule is still
+// queued or running, so slow crawls never overlap.
+type Scheduler struct {
+	launcher Launcher
+	options  OptionsFunc
+	clock    Clock
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{} // nil until the worker is started
+}
+
+type entry struct {
+	info   models.Schedule
+	timing timing
+}
+
+// timing computes when a schedule is due next after t
+type timing interface {
+	Next(t time.Time) time.Time
+}
+
+// cronTiming evaluates a cron expression in a time zone
+type cronTiming struct {
+	spec cron.Schedule
+	loc  *time.Location
+}
+
+func (c cronTiming) Next(t time.Time) time.Time {
+	return c.spec.Next(t.In(c.loc))
+}
+
+// intervalTiming repeats at a fixed interval
+type intervalTiming time.Duration
+
+func (i intervalTiming) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// New creates a scheduler starting jobs through launcher. A nil clock
+// uses the wall clock.
+func New(launcher Launcher, options OptionsFunc, clock Clock) *Scheduler {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &Scheduler{
+		launcher: launcher,
+		options:  options,
+		clock:    clock,
+		entries:  make(map[string]*entry),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs the worker starting due jobs until Stop is called
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done != nil {
+		return
+	}
+	s.done = make(chan struct{})
+	go s.loop(s.done)
+}
+
+// Stop ends the worker; jobs already started keep running
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	done := s.done
+	select {
+	case <-s.stop:
+		s.mu.Unlock()
+		return
+	default:
+		close(s.stop)
+	}
+	s.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// Create adds a schedule
+func (s *Scheduler) Create(req models.ScheduleRequest) (models.Schedule, error) {
+	t, err := s.parse(req)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+
+	now := s.clock.Now()
+	e := &entry{
+		info: models.Schedule{
+			ID:              newID(),
+			ScheduleRequest: req,
+			CreatedAt:       now,
+		},
+		timing: t,
+	}
+	e.plan(now)
+
+	s.mu.Lock()
+	s.entries[e.info.ID] = e
+	s.mu.Unlock()
+
+	s.notify()
+	return e.info, nil
+}
+
+// Update replaces the request and timing of a schedule, keeping its run history
+func (s *Scheduler) Update(id string, req models.ScheduleRequest) (models.Schedule, error) {
+	t, err := s.parse(req)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+
+	s.mu.Lock()
+	e, ok := s.entries[id]
+	if !ok {
+		s.mu.Unlock()
+		return models.Schedule{}, ErrNotFound
+	}
+	e.info.ScheduleRequest = req
+	e.timing = t
+	e.plan(s.clock.Now())
+	info := e.info
+	s.mu.Unlock()
+
+	s.notify()
+	return info, nil
+}
+
+// Get returns a schedule with the state of its last job
+func (s *Scheduler) Get(id string) (models.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[id]
+	if !ok {
+		return models.Schedule{}, ErrNotFound
+	}
+	s.refresh(e)
+	return e.info, nil
+}
+
+// List returns every schedule, oldest first
+func (s *Scheduler) List() []models.Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedules := make([]models.Schedule, 0, len(s.entries))
+	for _, e := range s.entries {
+		s.refresh(e)
+		schedules = append(schedules, e.info)
+	}
+	slices.SortFunc(schedules, func(a, b models.Schedule) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return schedules
+}
+
+// Delete removes a schedule; a job it already started keeps running
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, id)
+	return nil
+}
+
+// parse validates a schedule request and returns its timing
+func (s *Scheduler) parse(req models.ScheduleRequest) (timing, error) {
+	if _, err := s.options(req.Request); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.Cron != "" && req.Interval != "":
+		return nil, fmt.Errorf("set either cron or interval, not both")
+	case req.Cron != "":
+		loc := time.UTC
+		if req.Timezone != "" {
+			var err error
+			if loc, err = time.LoadLocation(req.Timezone); err != nil {
+				return nil, fmt.Errorf("invalid timezone %q: %v", req.Timezone, err)
+			}
+		}
+		if strings.HasPrefix(req.Cron, "TZ=") || strings.HasPrefix(req.Cron, "CRON_TZ=") {
+			return nil, fmt.Errorf("use timezone instead of a TZ prefix in cron")
+		}
+		spec, err := cron.ParseStandard(req.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", req.Cron, err)
+		}
+		return cronTiming{spec: spec, loc: loc}, nil
+	case req.Interval != "":
+		d, err := time.ParseDuration(req.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval %q", req.Interval)
+		}
+		if d < MinInterval {
+			return nil, fmt.Errorf("interval must be at least %s", MinInterval)
+		}
+		return intervalTiming(d), nil
+	default:
+		return nil, fmt.Errorf("cron or interval is required")
+	}
+}
+
+// plan sets when the schedule is due next after now
+func (e *entry) plan(now time.Time) {
+	if e.info.Paused {
+		e.info.NextRun = nil
+		return
+	}
+	next := e.timing.Next(now)
+	e.info.NextRun = &next
+}
+
+// busy reports whether the last job of a schedule may still be queued or running
+func (e *entry) busy() bool {
+	return e.info.LastJobID != "" && e.info.LastState != "" && !e.info.LastState.Finished()
+}
+
+// refresh updates the state of the last job; s.mu must be held
+func (s *Scheduler) refresh(e *entry) {
+	if !e.busy() {
+		return
+	}
+	job, err := s.launcher.Get(e.info.LastJobID)
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		// Only finished jobs are dropped, so the job is over even though
+		// its outcome is no longer known
+		e.info.LastState = ""
+	case err != nil:
+		log.Printf("Failed to get job %s of schedule %s: %v", e.info.LastJobID, e.info.ID, err)
+	default:
+		e.info.LastState = job.State
+	}
+}
+
+// notify wakes the worker so it picks up a changed schedule
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop(done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-s.clock.After(s.sleep()):
+			s.runDue()
+		case <-s.wake:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// sleep returns how long the worker can wait before the next schedule is due
+func (s *Scheduler) sleep() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	wait := maxSleep
+	for _, e := range s.entries {
+		if e.info.NextRun != nil {
+			wait = min(wait, e.info.NextRun.Sub(now))
+		}
+	}
+	return max(wait, 0)
+}
+
+// runDue starts a job for every schedule that is due. Occurrences missed
+// while the server was down or a job was still running are not caught up.
+func (s *Scheduler) runDue() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for _, e := range s.entries {
+		if e.info.NextRun == nil || e.info.NextRun.After(now) {
+			continue
+		}
+		e.plan(now)
+
+		s.refresh(e)
+		if e.busy() {
+			log.Printf("Skipping scheduled crawl %s of %s: job %s is still %s", e.info.ID, e.info.Request.URL, e.info.LastJobID, e.info.LastState)
+			e.info.Skipped++
+			continue
+		}
+
+		opts, err := s.options(e.info.Request)
+		if err != nil {
+			// Server limits may have changed since the schedule was created
+			log.Printf("Failed to start scheduled crawl %s of %s: %v", e.info.ID, e.info.Request.URL, err)
+			e.info.LastState = models.JobFailed
+			continue
+		}
+		job := s.launcher.Submit(e.info.Request, opts)
+		log.Printf("Started job %s for scheduled crawl %s of %s", job.ID, e.info.ID, e.info.Request.URL)
+		started := now
+		e.info.LastRun = &started
+		e.info.LastJobID = job.ID
+		e.info.LastState = job.State
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,293 @@
+package schedule
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	"github.com/aocamilo/broken-links-tester/pkg/crawler"
+	"github.com/aocamilo/broken-links-tester/pkg/jobs"
+)
+
+// fakeClock only moves when advanced
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires the timers that are due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// fakeLauncher records submitted jobs, which stay running until finished
+type fakeLauncher struct {
+	mu   sync.Mutex
+	jobs map[string]models.Job
+	urls []string
+}
+
+func newFakeLauncher() *fakeLauncher {
+	return &fakeLauncher{jobs: make(map[string]models.Job)}
+}
+
+func (l *fakeLauncher) Submit(req models.CheckRequest, opts crawler.CrawlOptions) models.Job {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.urls = append(l.urls, req.URL)
+	job := models.Job{ID: fmt.Sprintf("job-%d", len(l.urls)), URL: req.URL, State: models.JobRunning}
+	l.jobs[job.ID] = job
+	return job
+}
+
+func (l *fakeLauncher) Get(id string) (models.Job, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	job, ok := l.jobs[id]
+	if !ok {
+		return models.Job{}, jobs.ErrNotFound
+	}
+	return job, nil
+}
+
+func (l *fakeLauncher) finish(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	job := l.jobs[id]
+	job.State = models.JobDone
+	l.jobs[id] = job
+}
+
+// forget drops a job like the registry does once its retention expired
+func (l *fakeLauncher) forget(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.jobs, id)
+}
+
+func (l *fakeLauncher) submitted() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.urls)
+}
+
+func defaultOptions(models.CheckRequest) (crawler.CrawlOptions, error) {
+	return crawler.DefaultCrawlOptions(), nil
+}
+
+var start = time.Date(2024, 3, 19, 1, 0, 0, 0, time.UTC)
+
+func newTestScheduler() (*Scheduler, *fakeLauncher, *fakeClock) {
+	launcher := newFakeLauncher()
+	clock := newFakeClock(start)
+	return New(launcher, defaultOptions, clock), launcher, clock
+}
+
+func intervalRequest(interval string) models.ScheduleRequest {
+	return models.ScheduleRequest{
+		Request:  models.CheckRequest{URL: "https://example.com", Depth: 1},
+		Interval: interval,
+	}
+}
+
+func TestCreateValidatesTiming(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.ScheduleRequest
+		wantErr bool
+	}{
+		{"cron", models.ScheduleRequest{Cron: "0 3 * * *"}, false},
+		{"descriptor", models.ScheduleRequest{Cron: "@daily"}, false},
+		{"cron with timezone", models.ScheduleRequest{Cron: "0 3 * * *", Timezone: "Europe/Berlin"}, false},
+		{"interval", models.ScheduleRequest{Interval: "6h"}, false},
+		{"minimum interval", models.ScheduleRequest{Interval: "1m"}, false},
+		{"neither", models.ScheduleRequest{}, true},
+		{"both", models.ScheduleRequest{Cron: "0 3 * * *", Interval: "1h"}, true},
+		{"invalid cron", models.ScheduleRequest{Cron: "every night"}, true},
+		{"timezone prefix", models.ScheduleRequest{Cron: "TZ=UTC 0 3 * * *"}, true},
+		{"unknown timezone", models.ScheduleRequest{Cron: "0 3 * * *", Timezone: "Mars/Olympus"}, true},
+		{"short interval", models.ScheduleRequest{Interval: "30s"}, true},
+		{"invalid interval", models.ScheduleRequest{Interval: "daily"}, true},
+		{"negative interval", models.ScheduleRequest{Interval: "-1h"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, _ := newTestScheduler()
+			_, err := s.Create(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(s.List()) != 1 {
+				t.Fatalf("schedule was not kept")
+			}
+		})
+	}
+}
+
+func TestCronNextRunUsesTimezone(t *testing.T) {
+	s, _, _ := newTestScheduler()
+	sched, err := s.Create(models.ScheduleRequest{Cron: "0 3 * * *", Timezone: "Europe/Berlin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 03:00 in Berlin is 02:00 UTC in March
+	want := time.Date(2024, 3, 19, 2, 0, 0, 0, time.UTC)
+	if sched.NextRun == nil || !sched.NextRun.Equal(want) {
+		t.Fatalf("NextRun = %v, want %v", sched.NextRun, want)
+	}
+}
+
+func TestDueScheduleStartsJob(t *testing.T) {
+	s, launcher, clock := newTestScheduler()
+	sched, err := s.Create(intervalRequest("1h"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(59 * time.Minute)
+	s.runDue()
+	if n := launcher.submitted(); n != 0 {
+		t.Fatalf("started %d jobs before the schedule was due", n)
+	}
+
+	clock.Advance(time.Minute)
+	s.runDue()
+	if n := launcher.submitted(); n != 1 {
+		t.Fatalf("started %d jobs, want 1", n)
+	}
+
+	got, _ := s.Get(sched.ID)
+	if got.LastRun == nil || !got.LastRun.Equal(clock.Now()) {
+		t.Errorf("LastRun = %v, want %v", got.LastRun, clock.Now())
+	}
+	if got.LastJobID != "job-1" || got.LastState != models.JobRunning {
+		t.Errorf("last job = %s %s, want job-1 running", got.LastJobID, got.LastState)
+	}
+	if want := clock.Now().Add(time.Hour); got.NextRun == nil || !got.NextRun.Equal(want) {
+		t.Errorf("NextRun = %v, want %v", got.NextRun, want)
+	}
+}
+
+func TestOverlappingRunIsSkipped(t *testing.T) {
+	s, launcher, clock := newTestScheduler()
+	sched, _ := s.Create(intervalRequest("1h"))
+
+	clock.Advance(time.Hour)
+	s.runDue()
+	clock.Advance(time.Hour)
+	s.runDue()
+
+	got, _ := s.Get(sched.ID)
+	if n := launcher.submitted(); n != 1 {
+		t.Fatalf("started %d jobs while the first was running, want 1", n)
+	}
+	if got.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", got.Skipped)
+	}
+
+	launcher.finish(got.LastJobID)
+	clock.Advance(time.Hour)
+	s.runDue()
+	if n := launcher.submitted(); n != 2 {
+		t.Fatalf("started %d jobs after the first finished, want 2", n)
+	}
+}
+
+func TestForgottenJobDoesNotBlockSchedule(t *testing.T) {
+	s, launcher, clock := newTestScheduler()
+	sched, _ := s.Create(intervalRequest("2h"))
+
+	clock.Advance(2 * time.Hour)
+	s.runDue()
+	launcher.forget("job-1")
+
+	clock.Advance(2 * time.Hour)
+	s.runDue()
+	got, _ := s.Get(sched.ID)
+	if n := launcher.submitted(); n != 2 {
+		t.Fatalf("started %d jobs, want 2", n)
+	}
+	if got.Skipped != 0 {
+		t.Errorf("Skipped = %d, want 0", got.Skipped)
+	}
+}
+
+func TestPausedScheduleDoesNotRun(t *testing.T) {
+	s, launcher, clock := newTestScheduler()
+	req := intervalRequest("1h")
+	req.Paused = true
+	sched, _ := s.Create(req)
+	if sched.NextRun != nil {
+		t.Fatalf("paused schedule has NextRun %v", sched.NextRun)
+	}
+
+	clock.Advance(3 * time.Hour)
+	s.runDue()
+	if n := launcher.submitted(); n != 0 {
+		t.Fatalf("paused schedule started %d jobs", n)
+	}
+
+	req.Paused = false
+	sched, err := s.Update(sched.ID, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.Now().Add(time.Hour); sched.NextRun == nil || !sched.NextRun.Equal(want) {
+		t.Errorf("NextRun = %v, want %v", sched.NextRun, want)
+	}
+}
+
+func TestWorkerRunsOnClock(t *testing.T) {
+	s, launcher, clock := newTestScheduler()
+	s.Start()
+	defer s.Stop()
+	s.Create(intervalRequest("1m"))
+
+	// The worker may not have picked up the new schedule yet, so keep
+	// advancing until it started a job
+	deadline := time.Now().Add(5 * time.Second)
+	for launcher.submitted() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("worker never started the due job")
+		}
+		clock.Advance(time.Minute)
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the bolt database, all keyed by run ID
+var (
+	runsBucket    = []byte("runs")    // Runs without their results
+	resultsBucket = []byte("results") // Results of every run
+	graphsBucket  = []byte("graphs")  // Link graph of every run
+)
+
+// BoltRepository keeps runs in an embedded bbolt database file
+type BoltRepository struct {
+	db *bolt.DB
+}
+
+// OpenBoltRepository opens or creates the database at path
+func OpenBoltRepository(path string) (*BoltRepository, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run database: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{runsBucket, resultsBucket, graphsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create run buckets: %v", err)
+	}
+	return &BoltRepository{db: db}, nil
+}
+
+func (b *BoltRepository) Save(run *models.Run, graph models.LinkGraph) error {
+	if run.ID == "" {
+		run.ID = newID()
+	}
+	summary := *run
+	summary.Results = nil
+
+	runData, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode run: %v", err)
+	}
+	resultsData, err := json.Marshal(run.Results)
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %v", err)
+	}
+	graphData, err := json.Marshal(graph)
+	if err != nil {
+		return fmt.Errorf("failed to encode graph: %v", err)
+	}
+
+	key := []byte(run.ID)
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(runsBucket).Put(key, runData); err != nil {
+			return err
+		}
+		if err := tx.Bucket(resultsBucket).Put(key, resultsData); err != nil {
+			return err
+		}
+		return tx.Bucket(graphsBucket).Put(key, graphData)
+	})
+}
+
+func (b *BoltRepository) Get(id string) (models.Run, error) {
+	var run models.Run
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(runsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(data, &run); err != nil {
+			return fmt.Errorf("failed to decode run %s: %v", id, err)
+		}
+		if data := tx.Bucket(resultsBucket).Get([]byte(id)); data != nil {
+			if err := json.Unmarshal(data, &run.Results); err != nil {
+				return fmt.Errorf("failed to decode results of run %s: %v", id, err)
+			}
+		}
+		return nil
+	})
+	return run, err
+}
+
+func (b *BoltRepository) List(filter Filter) ([]models.Run, error) {
+	runs := make([]models.Run, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(runsBucket).ForEach(func(k, v []byte) error {
+			var run models.Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return fmt.Errorf("failed to decode run %s: %v", k, err)
+			}
+			if filter.matches(run) {
+				runs = append(runs, run)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newestFirst(runs, filter.Limit), nil
+}
+
+func (b *BoltRepository) Graph(id string) (models.LinkGraph, error) {
+	var graph models.LinkGraph
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(graphsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(data, &graph); err != nil {
+			return fmt.Errorf("failed to decode graph of run %s: %v", id, err)
+		}
+		return nil
+	})
+	return graph, err
+}
+
+func (b *BoltRepository) Delete(id string) error {
+	key := []byte(id)
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(runsBucket).Get(key) == nil {
+			return ErrNotFound
+		}
+		for _, name := range [][]byte{runsBucket, resultsBucket, graphsBucket} {
+			if err := tx.Bucket(name).Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *BoltRepository) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// MemoryRepository keeps runs in memory; they are lost on restart
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	runs   map[string]models.Run
+	graphs map[string]models.LinkGraph
+}
+
+// NewMemoryRepository creates an empty in-memory repository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		runs:   make(map[string]models.Run),
+		graphs: make(map[string]models.LinkGraph),
+	}
+}
+
+func (m *MemoryRepository) Save(run *models.Run, graph models.LinkGraph) error {
+	if run.ID == "" {
+		run.ID = newID()
+	}
+	stored := *run
+	stored.Results = slices.Clone(run.Results)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[run.ID] = stored
+	m.graphs[run.ID] = graph
+	return nil
+}
+
+func (m *MemoryRepository) Get(id string) (models.Run, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	run, ok := m.runs[id]
+	if !ok {
+		return models.Run{}, ErrNotFound
+	}
+	run.Results = slices.Clone(run.Results)
+	return run, nil
+}
+
+func (m *MemoryRepository) List(filter Filter) ([]models.Run, error) {
+	m.mu.RLock()
+	runs := make([]models.Run, 0, len(m.runs))
+	for _, run := range m.runs {
+		if filter.matches(run) {
+			run.Results = nil
+			runs = append(runs, run)
+		}
+	}
+	m.mu.RUnlock()
+	return newestFirst(runs, filter.Limit), nil
+}
+
+func (m *MemoryRepository) Graph(id string) (models.LinkGraph, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	graph, ok := m.graphs[id]
+	if !ok {
+		return models.LinkGraph{}, ErrNotFound
+	}
+	return graph, nil
+}
+
+func (m *MemoryRepository) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.runs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.runs, id)
+	delete(m.graphs, id)
+	return nil
+}
+
+func (m *MemoryRepository) Close() error {
+	return nil
+}
+
+// newestFirst sorts runs by finish time, most recent first, and keeps at most limit
+func newestFirst(runs []models.Run, limit int) []models.Run {
+	slices.SortFunc(runs, func(a, b models.Run) int {
+		return b.FinishedAt.Compare(a.FinishedAt)
+	})
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs
+}
@@ -0,0 +1,98 @@
+// Package store keeps finished crawl runs, their results and link graphs
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+// ErrNotFound is returned when no run has the requested ID
+var ErrNotFound = errors.New("run not found")
+
+// RunRepository persists crawl runs. Implementations must be safe for
+// concurrent use.
+type RunRepository interface {
+	// Save stores a run with its results and link graph, replacing any run
+	// with the same ID. A run without an ID is given a new one.
+	Save(run *models.Run, graph models.LinkGraph) error
+	// Get returns a run together with its results
+	Get(id string) (models.Run, error)
+	// List returns runs without their results, most recent first
+	List(filter Filter) ([]models.Run, error)
+	// Graph returns the link graph of a run
+	Graph(id string) (models.LinkGraph, error)
+	// Delete removes a run with its results and graph
+	Delete(id string) error
+	Close() error
+}
+
+// Filter selects the runs returned by List
+type Filter struct {
+	URL    string    // Only runs of this start URL
+	Before time.Time // Only runs finished before this time
+	Limit  int       // At most this many runs; zero means all
+}
+
+// Retention decides how long runs are kept
+type Retention struct {
+	MaxAge  time.Duration // Runs finished longer ago are deleted; zero keeps them
+	MaxRuns int           // Only the most recent runs are kept; zero keeps all
+}
+
+// DefaultRetention keeps a month of runs, up to a thousand
+func DefaultRetention() Retention {
+	return Retention{
+		MaxAge:  30 * 24 * time.Hour,
+		MaxRuns: 1000,
+	}
+}
+
+// Prune deletes the runs the retention policy no longer keeps
+func Prune(repo RunRepository, retention Retention, now time.Time) (int, error) {
+	runs, err := repo.List(Filter{})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for i, run := range runs {
+		expired := retention.MaxAge > 0 && run.FinishedAt.Before(now.Add(-retention.MaxAge))
+		excess := retention.MaxRuns > 0 && i >= retention.MaxRuns
+		if !expired && !excess {
+			continue
+		}
+		if err := repo.Delete(run.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return deleted, fmt.Errorf("failed to delete run %s: %v", run.ID, err)
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d runs past their retention", deleted)
+	}
+	return deleted, nil
+}
+
+// matches reports whether a run passes the filter
+func (f Filter) matches(run models.Run) bool {
+	if f.URL != "" && run.URL != f.URL {
+		return false
+	}
+	if !f.Before.IsZero() && !run.FinishedAt.Before(f.Before) {
+		return false
+	}
+	return true
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,278 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aocamilo/broken-links-tester/internal/models"
+)
+
+var baseTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// repositories opens every RunRepository implementation for a test
+func repositories(t *testing.T) map[string]RunRepository {
+	t.Helper()
+	bolt, err := OpenBoltRepository(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	memory := NewMemoryRepository()
+	t.Cleanup(func() {
+		bolt.Close()
+		memory.Close()
+	})
+	return map[string]RunRepository{"memory": memory, "bolt": bolt}
+}
+
+// testRun builds a finished run of url that ended age after baseTime
+func testRun(url string, age time.Duration) *models.Run {
+	return &models.Run{
+		URL:         url,
+		Depth:       1,
+		Config:      models.CheckRequest{URL: url, Depth: 1},
+		State:       models.JobDone,
+		Summary:     models.LinkSummary{Total: 2, Working: 1, Broken: 1},
+		ErrorCounts: map[models.ErrorCategory]int{models.ErrorHTTPClient: 1},
+		StartedAt:   baseTime.Add(age - time.Minute),
+		FinishedAt:  baseTime.Add(age),
+		Results: []models.LinkStatus{
+			{URL: url, StatusCode: 200, IsWorking: true, ResponseTime: "10ms", LastChecked: baseTime},
+			{
+				URL:           url + "missing",
+				StatusCode:    404,
+				ErrorCategory: models.ErrorHTTPClient,
+				ParentURL:     url,
+				Referrers:     []string{url},
+				Sources:       []models.LinkSource{{ParentURL: url, Text: "Missing", Line: 3, Column: 5}},
+				ResponseTime:  "5ms",
+				LastChecked:   baseTime,
+			},
+		},
+	}
+}
+
+func testGraph(url string) models.LinkGraph {
+	return models.LinkGraph{
+		Nodes: []models.LinkNode{{URL: url, StatusCode: 200, IsWorking: true}, {URL: url + "missing", StatusCode: 404}},
+		Edges: []models.LinkEdge{{Source: url, Target: url + "missing", Count: 1}},
+	}
+}
+
+func TestRepositoryRoundTrip(t *testing.T) {
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			run := testRun("https://example.com/", 0)
+			graph := testGraph("https://example.com/")
+			if err := repo.Save(run, graph); err != nil {
+				t.Fatal(err)
+			}
+			if run.ID == "" {
+				t.Fatal("Save did not assign an ID")
+			}
+
+			got, err := repo.Get(run.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, *run) {
+				t.Errorf("Get() = %+v, want %+v", got, *run)
+			}
+			gotGraph, err := repo.Graph(run.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotGraph, graph) {
+				t.Errorf("Graph() = %+v, want %+v", gotGraph, graph)
+			}
+
+			listed, err := repo.List(Filter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(listed) != 1 || listed[0].ID != run.ID || listed[0].Results != nil {
+				t.Errorf("List() = %+v, want the run without results", listed)
+			}
+		})
+	}
+}
+
+func TestRepositorySaveReplaces(t *testing.T) {
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			run := testRun("https://example.com/", 0)
+			if err := repo.Save(run, testGraph(run.URL)); err != nil {
+				t.Fatal(err)
+			}
+			run.State = models.JobFailed
+			run.Results = run.Results[:1]
+			if err := repo.Save(run, models.LinkGraph{}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := repo.Get(run.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.State != models.JobFailed || len(got.Results) != 1 {
+				t.Errorf("Get() = state %s with %d results, want failed with 1", got.State, len(got.Results))
+			}
+			if runs, _ := repo.List(Filter{}); len(runs) != 1 {
+				t.Errorf("List() returned %d runs, want 1", len(runs))
+			}
+		})
+	}
+}
+
+func TestRepositoryNotFound(t *testing.T) {
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := repo.Get("missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() error = %v, want ErrNotFound", err)
+			}
+			if _, err := repo.Graph("missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Graph() error = %v, want ErrNotFound", err)
+			}
+			if err := repo.Delete("missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete() error = %v, want ErrNotFound", err)
+			}
+
+			run := testRun("https://example.com/", 0)
+			if err := repo.Save(run, testGraph(run.URL)); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Delete(run.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.Get(run.ID); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() after Delete error = %v, want ErrNotFound", err)
+			}
+			if _, err := repo.Graph(run.ID); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Graph() after Delete error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestRepositoryList(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string // Run IDs in order
+	}{
+		{"all newest first", Filter{}, []string{"c", "b", "a"}},
+		{"limit", Filter{Limit: 2}, []string{"c", "b"}},
+		{"url", Filter{URL: "https://other.com/"}, []string{"b"}},
+		{"before", Filter{Before: baseTime.Add(2 * time.Hour)}, []string{"b", "a"}},
+	}
+	for name, repo := range repositories(t) {
+		for id, run := range map[string]*models.Run{
+			"a": testRun("https://example.com/", 0),
+			"b": testRun("https://other.com/", time.Hour),
+			"c": testRun("https://example.com/", 2*time.Hour),
+		} {
+			run.ID = id
+			if err := repo.Save(run, testGraph(run.URL)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				runs, err := repo.List(tt.filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := make([]string, 0, len(runs))
+				for _, run := range runs {
+					ids = append(ids, run.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.want) {
+					t.Errorf("List(%+v) = %v, want %v", tt.filter, ids, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestBoltRepositoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.db")
+	repo, err := OpenBoltRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := testRun("https://example.com/", 0)
+	if err := repo.Save(run, testGraph(run.URL)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err = OpenBoltRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	got, err := repo.Get(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, *run) {
+		t.Errorf("Get() after reopening = %+v, want %+v", got, *run)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := baseTime.Add(10 * 24 * time.Hour)
+	tests := []struct {
+		name        string
+		retention   Retention
+		wantDeleted int
+		want        []string // Remaining run IDs, newest first
+	}{
+		{"keep all", Retention{}, 0, []string{"today", "week", "month"}},
+		{"max age", Retention{MaxAge: 5 * 24 * time.Hour}, 2, []string{"today"}},
+		{"max runs", Retention{MaxRuns: 2}, 1, []string{"today", "week"}},
+		{"both", Retention{MaxAge: 8 * 24 * time.Hour, MaxRuns: 1}, 2, []string{"today"}},
+	}
+	for name := range repositories(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				repo := repositories(t)[name]
+				for id, finished := range map[string]time.Time{
+					"today": now.Add(-time.Hour),
+					"week":  now.Add(-7 * 24 * time.Hour),
+					"month": now.Add(-30 * 24 * time.Hour),
+				} {
+					run := testRun("https://example.com/", 0)
+					run.ID = id
+					run.FinishedAt = finished
+					if err := repo.Save(run, testGraph(run.URL)); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				deleted, err := Prune(repo, tt.retention, now)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if deleted != tt.wantDeleted {
+					t.Errorf("Prune() deleted %d runs, want %d", deleted, tt.wantDeleted)
+				}
+				runs, err := repo.List(Filter{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := make([]string, 0, len(runs))
+				for _, run := range runs {
+					ids = append(ids, run.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.want) {
+					t.Errorf("runs left = %v, want %v", ids, tt.want)
+				}
+			})
+		}
+	}
+}